@@ -0,0 +1,308 @@
+package kvg
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Affine transforms of paths, groups and stroke-number labels.
+
+// An affine transform, with the same meaning as the SVG transform
+// "matrix(A B C D E F)", which takes x, y to
+//
+//	A*x + C*y + E, B*x + D*y + F
+type Affine struct {
+	A, B, C, D, E, F float64
+}
+
+// The transform which leaves everything where it is.
+var Identity = Affine{A: 1, D: 1}
+
+// Make a transform which moves everything by x, y.
+func Translate(x, y float64) Affine {
+	return Affine{A: 1, D: 1, E: x, F: y}
+}
+
+// Make a transform which scales x by sx and y by sy about the origin.
+// A negative scale mirrors, so Scale(-1, 1) followed by
+// Translate(109, 0) mirrors a kanji from left to right.
+func Scale(sx, sy float64) Affine {
+	return Affine{A: sx, D: sy}
+}
+
+// Make a transform which rotates by deg degrees about the origin,
+// clockwise as seen on the screen, as in the SVG "rotate(deg)".
+func Rotate(deg float64) Affine {
+	s, c := math.Sincos(deg * math.Pi / 180)
+	return Affine{A: c, B: s, C: -s, D: c}
+}
+
+// Make a transform which puts the rectangle from into the rectangle
+// to, such as Fit(box, Rect{Point{10, 10}, Point{50, 50}}) to put a
+// component into a new position and size. Each direction is scaled
+// separately. A direction in which from has no size, such as across a
+// single vertical stroke, is not scaled, and from is put in the middle
+// of to in that direction.
+func Fit(from, to Rect) Affine {
+	sx, sy := 1.0, 1.0
+	size := from.Max.sub(from.Min)
+	// Where from.Min goes.
+	at := to.Min
+	if size.X > 0 {
+		sx = (to.Max.X - to.Min.X) / size.X
+	} else {
+		at.X = (to.Min.X + to.Max.X) / 2
+	}
+	if size.Y > 0 {
+		sy = (to.Max.Y - to.Min.Y) / size.Y
+	} else {
+		at.Y = (to.Min.Y + to.Max.Y) / 2
+	}
+	return Translate(-from.Min.X, -from.Min.Y).
+		Then(Scale(sx, sy)).
+		Then(Translate(at.X, at.Y))
+}
+
+// Make the transform which does m and then n.
+func (m Affine) Then(n Affine) Affine {
+	return Affine{
+		A: n.A*m.A + n.C*m.B,
+		B: n.B*m.A + n.D*m.B,
+		C: n.A*m.C + n.C*m.D,
+		D: n.B*m.C + n.D*m.D,
+		E: n.A*m.E + n.C*m.F + n.E,
+		F: n.B*m.E + n.D*m.F + n.F,
+	}
+}
+
+// Move p by m.
+func (m Affine) Point(p Point) Point {
+	return Point{m.A*p.X + m.C*p.Y + m.E, m.B*p.X + m.D*p.Y + m.F}
+}
+
+// Move the vector v by m, which is the same as Point but without the
+// translation. This is how relative coordinates change.
+func (m Affine) Vector(v Point) Point {
+	return Point{m.A*v.X + m.C*v.Y, m.B*v.X + m.D*v.Y}
+}
+
+// Does m take horizontal lines to horizontal lines and vertical lines
+// to vertical lines?
+func (m Affine) axisAligned() bool {
+	return m.B == 0 && m.C == 0
+}
+
+// Write m as the value of a transform attribute, such as
+// "matrix(1 0 0 1 11.5 16.13)". See Transform.String for the format
+// of the numbers.
+func (m Affine) String() string {
+	return m.Transform().String()
+}
+
+// Make m into a Transform with a single matrix.
+func (m Affine) Transform() Transform {
+	return Transform{Ops: []TransformOp{
+		{"matrix", []float64{m.A, m.B, m.C, m.D, m.E, m.F}},
+	}}
+}
+
+// Read the value of a transform attribute, which is a list of
+// transforms such as "translate(10 20) scale(2)", as a single Affine.
+// The empty string gives Identity. See ParseTransformList for a
+// version which keeps the transforms of the list.
+func ParseTransform(s string) (m Affine, err error) {
+	t, err := ParseTransformList(s)
+	if err != nil {
+		return Identity, err
+	}
+	return t.Affine(), nil
+}
+
+// Make the transform called name with parameters v, or give false if
+// there are the wrong number of parameters.
+func makeTransform(name string, v []float64) (m Affine, ok bool) {
+	switch {
+	case name == "matrix" && len(v) == 6:
+		return Affine{v[0], v[1], v[2], v[3], v[4], v[5]}, true
+	case name == "translate" && len(v) == 1:
+		return Translate(v[0], 0), true
+	case name == "translate" && len(v) == 2:
+		return Translate(v[0], v[1]), true
+	case name == "scale" && len(v) == 1:
+		return Scale(v[0], v[0]), true
+	case name == "scale" && len(v) == 2:
+		return Scale(v[0], v[1]), true
+	case name == "rotate" && len(v) == 1:
+		return Rotate(v[0]), true
+	case name == "rotate" && len(v) == 3:
+		return Translate(-v[1], -v[2]).Then(Rotate(v[0])).Then(Translate(v[1], v[2])), true
+	case name == "skewX" && len(v) == 1:
+		return Affine{A: 1, C: math.Tan(v[0] * math.Pi / 180), D: 1}, true
+	case name == "skewY" && len(v) == 1:
+		return Affine{A: 1, B: math.Tan(v[0] * math.Pi / 180), D: 1}, true
+	}
+	return m, false
+}
+
+// Get the new current point after c, from the current point x, y and
+// the start of the subpath startX, startY.
+func (c Command) advance(x, y, startX, startY float64) (float64, float64) {
+	p := c.Params
+	abs := c.IsAbsolute()
+	switch strings.ToLower(c.Symbol) {
+	case "z":
+		return startX, startY
+	case "h":
+		if abs {
+			return p[0], y
+		}
+		return x + p[0], y
+	case "v":
+		if abs {
+			return x, p[0]
+		}
+		return x, y + p[0]
+	}
+	nx, ny := p[len(p)-2], p[len(p)-1]
+	if !abs {
+		nx, ny = x+nx, y+ny
+	}
+	return nx, ny
+}
+
+// Make a copy of p moved by m. Absolute and relative commands stay
+// absolute and relative, and a relative moveto at the start of p is
+// moved as an absolute one, since that is what it is. Horizontal and
+// vertical lines become general lines unless m keeps them horizontal
+// and vertical, and the radii, rotation and sweep of arcs are changed
+// to fit.
+func (p SVGPath) Apply(m Affine) (o SVGPath) {
+	var x, y, startX, startY float64
+	for si, s := range p.Subpaths {
+		var os Subpath
+		for ci, c := range s.Commands {
+			abs := c.IsAbsolute() || si == 0 && ci == 0
+			move := m.Vector
+			if abs {
+				move = m.Point
+			}
+			n := Command{Symbol: c.Symbol, Implicit: c.Implicit}
+			params := c.Params
+			switch strings.ToLower(c.Symbol) {
+			case "h", "v":
+				if m.axisAligned() {
+					v := params[0]
+					if c.Symbol == "h" || c.Symbol == "H" {
+						v *= m.A
+						if abs {
+							v += m.E
+						}
+					} else {
+						v *= m.D
+						if abs {
+							v += m.F
+						}
+					}
+					n.Params = []float64{v}
+					break
+				}
+				nx, ny := c.advance(x, y, startX, startY)
+				end := Point{nx - x, ny - y}
+				n.Symbol = "l"
+				if abs {
+					end = Point{nx, ny}
+					n.Symbol = "L"
+				}
+				end = move(end)
+				n.Params = []float64{end.X, end.Y}
+			case "a":
+				n.Params = append([]float64{}, params...)
+				n.Params[0], n.Params[1], n.Params[2] = m.ellipse(params[0], params[1], params[2])
+				if m.A*m.D-m.B*m.C < 0 {
+					n.Params[4] = 1 - params[4]
+				}
+				end := move(Point{params[5], params[6]})
+				n.Params[5], n.Params[6] = end.X, end.Y
+			default:
+				n.Params = make([]float64, len(params))
+				for i := 0; i+1 < len(params); i += 2 {
+					q := move(Point{params[i], params[i+1]})
+					n.Params[i], n.Params[i+1] = q.X, q.Y
+				}
+			}
+			x, y = c.advance(x, y, startX, startY)
+			if strings.ToLower(c.Symbol) == "m" {
+				startX, startY = x, y
+			}
+			os.Commands = append(os.Commands, n)
+		}
+		o.Subpaths = append(o.Subpaths, os)
+	}
+	return o
+}
+
+// Get the radii and rotation in degrees of the ellipse with radii rx,
+// ry and rotation deg after it is moved by m.
+func (m Affine) ellipse(rx, ry, deg float64) (nrx, nry, ndeg float64) {
+	s, c := math.Sincos(deg * math.Pi / 180)
+	// The columns of e are the axes of the moved ellipse, which are
+	// not in general at right angles. The new axes are the
+	// eigenvectors of e times its transpose.
+	e := Affine{A: rx * c, B: rx * s, C: -ry * s, D: ry * c}.Then(Affine{A: m.A, B: m.B, C: m.C, D: m.D})
+	p := e.A*e.A + e.C*e.C
+	q := e.A*e.B + e.C*e.D
+	r := e.B*e.B + e.D*e.D
+	mid := (p + r) / 2
+	diff := math.Hypot((p-r)/2, q)
+	nrx = math.Sqrt(mid + diff)
+	nry = math.Sqrt(math.Max(0, mid-diff))
+	ndeg = math.Atan2(2*q, p-r) / 2 * 180 / math.Pi
+	return nrx, nry, ndeg
+}
+
+// The format of the 'd' attributes written by Path.Apply.
+var TransformFormat = PathFormat{
+	Precision:   2,
+	KeepElision: true,
+}
+
+// Move p by m, and write the result back to p.D using
+// TransformFormat.
+func (p *Path) Apply(m Affine) (err error) {
+	path, err := p.Parse()
+	if err != nil {
+		return err
+	}
+	p.D = TransformFormat.Encode(path.Apply(m))
+	return nil
+}
+
+// Move all of the paths in g and its subgroups by m. This does not
+// move the stroke-number labels of the paths. All of the paths are
+// read before any is moved, so if one cannot be read g is left as it
+// was.
+func (g *Group) Apply(m Affine) (err error) {
+	ps := g.GetPaths()
+	paths := make([]SVGPath, len(ps))
+	for i, p := range ps {
+		paths[i], err = p.Parse()
+		if err != nil {
+			return fmt.Errorf("%s: %w", p.ID, err)
+		}
+	}
+	for i, p := range ps {
+		p.D = TransformFormat.Encode(paths[i].Apply(m))
+	}
+	return nil
+}
+
+// Move the label t by m, by adding m to the end of its transform. The
+// new transform is a single matrix.
+func (t *Text) Apply(m Affine) (err error) {
+	if !t.Transform.Valid() {
+		return fmt.Errorf("bad transform %q", t.Transform)
+	}
+	t.Transform = t.Transform.Affine().Then(m).Transform()
+	return nil
+}
@@ -0,0 +1,82 @@
+package kvg
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestAffine(t *testing.T) {
+	raw := "M10,10h10v10l-10,0zm5,5c1,2,3,4,5,6s1,1,2,2L30,30q5,0,5,5t5,5"
+	path, err := PathParser(raw)
+	if err != nil {
+		t.Fatalf("Error parsing %s: %s", raw, err)
+	}
+	moves := []Affine{
+		Translate(3, 4),
+		Scale(-1, 1).Then(Translate(109, 0)),
+		Rotate(30),
+		Affine{1, 0.5, 0.25, 2, 7, -7},
+	}
+	for _, m := range moves {
+		moved := path.Apply(m)
+		for i := 0; i <= 20; i++ {
+			a, _ := path.PointAt(float64(i) / 20)
+			b, _ := moved.PointAt(float64(i) / 20)
+			if m.Point(a).sub(b).length() > 1e-9 {
+				t.Errorf("%v moved %v to %v, expected %v", m, a, b, m.Point(a))
+			}
+		}
+	}
+	got := path.Apply(Translate(1, 2)).String()
+	want := "M11,12h10v10l-10,0zm5,5c1,2,3,4,5,6s1,1,2,2L31,32q5,0,5,5t5,5"
+	if got != want {
+		t.Errorf("Translated %s to %s", raw, got)
+	}
+	// Stretch a circle upwards and then lay it on its side.
+	arc, _ := PathParser("M0,0a10,10,0,0,1,20,0")
+	a := arc.Apply(Scale(1, 2).Then(Rotate(90))).Subpaths[0].Commands[1].Params
+	if math.Abs(a[0]-20) > 1e-9 || math.Abs(a[1]-10) > 1e-9 ||
+		math.Abs(a[2]) > 1e-9 || a[4] != 1 {
+		t.Errorf("Bad arc %v", a)
+	}
+	m, err := ParseTransform("translate(10,20) scale(2)")
+	if err != nil || m != (Affine{2, 0, 0, 2, 10, 20}) {
+		t.Errorf("Bad transform %v: %v", m, err)
+	}
+	if _, err := ParseTransform("matrix(1 0 0 1)"); err == nil {
+		t.Errorf("No error for bad transform")
+	}
+	var text Text
+	text.Transform, _ = ParseTransformList("matrix(1 0 0 1 11.5 16.13)")
+	text.Apply(Translate(1, -1))
+	if text.Transform.String() != "matrix(1 0 0 1 12.5 15.13)" {
+		t.Errorf("Moved label to %s", text.Transform)
+	}
+	p := Path{D: "M10,10c1,2,3,4,5,6"}
+	p.Apply(Scale(2, 2))
+	if p.D != "M20,20c2,4,6,8,10,12" {
+		t.Errorf("Scaled path to %s", p.D)
+	}
+	path, _ = PathParser("m10,10l5,0")
+	if got := path.Apply(Translate(5, 5)).String(); got != "m15,15l5,0" {
+		t.Errorf("Moved a path with a relative first moveto to %s", got)
+	}
+	// A single vertical stroke has no width, so it is only scaled up
+	// and down, and is put in the middle across.
+	m = Fit(Rect{Point{10, 10}, Point{10, 30}}, Rect{Point{0, 0}, Point{20, 40}})
+	if m != (Affine{1, 0, 0, 2, 0, -20}) {
+		t.Errorf("Fitted a vertical line with %v", m)
+	}
+	g := Group{Children: []Child{
+		{Path: Path{ID: "a", D: "M10,10c1,2,3,4,5,6"}},
+		{Path: Path{ID: "b", D: "M10,10x"}},
+	}}
+	err = g.Apply(Scale(2, 2))
+	if err == nil || errors.Unwrap(err) == nil {
+		t.Errorf("Expected a wrapped error, got %v", err)
+	}
+	if g.Children[0].Path.D != "M10,10c1,2,3,4,5,6" {
+		t.Errorf("Group half moved: %s", g.Children[0].Path.D)
+	}
+}
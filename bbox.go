@@ -0,0 +1,172 @@
+package kvg
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Bounding boxes of paths, groups and whole kanji.
+
+// A rectangle with sides parallel to the axes. A rectangle whose Min
+// is greater than its Max, such as EmptyRect, contains no points.
+type Rect struct {
+	Min, Max Point
+}
+
+// The rectangle which contains nothing. The union of EmptyRect with a
+// rectangle r is r.
+var EmptyRect = Rect{
+	Point{math.Inf(1), math.Inf(1)},
+	Point{math.Inf(-1), math.Inf(-1)},
+}
+
+// Does r contain no points?
+func (r Rect) Empty() bool {
+	return r.Min.X > r.Max.X || r.Min.Y > r.Max.Y
+}
+
+// Get the smallest rectangle containing both r and o.
+func (r Rect) Union(o Rect) Rect {
+	return Rect{
+		Point{math.Min(r.Min.X, o.Min.X), math.Min(r.Min.Y, o.Min.Y)},
+		Point{math.Max(r.Max.X, o.Max.X), math.Max(r.Max.Y, o.Max.Y)},
+	}
+}
+
+// Is all of o inside r? An empty rectangle is inside every rectangle.
+func (r Rect) Contains(o Rect) bool {
+	if o.Empty() {
+		return true
+	}
+	return r.Min.X <= o.Min.X && r.Min.Y <= o.Min.Y &&
+		r.Max.X >= o.Max.X && r.Max.Y >= o.Max.Y
+}
+
+// Get the smallest rectangle containing r and p.
+func (r Rect) addPoint(p Point) Rect {
+	return r.Union(Rect{p, p})
+}
+
+func (r Rect) String() string {
+	return fmt.Sprintf("(%g,%g)-(%g,%g)", r.Min.X, r.Min.Y, r.Max.X, r.Max.Y)
+}
+
+// The values of t between 0 and 1 where the derivative of one
+// coordinate of s is zero. The coordinate is given by get.
+func (s segment) extrema(get func(Point) float64) (ts []float64) {
+	// The derivative as a polynomial at² + bt + c, from its Bernstein
+	// coefficients d.
+	var d [3]float64
+	for i := 0; i < s.n; i++ {
+		d[i] = get(s.p[i+1]) - get(s.p[i])
+	}
+	var a, b, c float64
+	if s.n == 3 {
+		a, b, c = d[0]-2*d[1]+d[2], 2*(d[1]-d[0]), d[0]
+	} else {
+		b, c = d[1]-d[0], d[0]
+	}
+	if a == 0 {
+		if b != 0 {
+			ts = append(ts, -c/b)
+		}
+	} else if disc := b*b - 4*a*c; disc >= 0 {
+		sq := math.Sqrt(disc)
+		ts = append(ts, (-b+sq)/(2*a), (-b-sq)/(2*a))
+	}
+	var inside []float64
+	for _, t := range ts {
+		if t > 0 && t < 1 {
+			inside = append(inside, t)
+		}
+	}
+	return inside
+}
+
+// The bounding box of the curve s, from its end points and the points
+// where it turns in x or y.
+func (s segment) bounds() Rect {
+	r := EmptyRect.addPoint(s.p[0]).addPoint(s.end())
+	x := func(p Point) float64 { return p.X }
+	y := func(p Point) float64 { return p.Y }
+	for _, t := range append(s.extrema(x), s.extrema(y)...) {
+		r = r.addPoint(s.point(t))
+	}
+	return r
+}
+
+// Get the smallest rectangle containing the curves of p. This is
+// EmptyRect if p has no curves.
+func (p SVGPath) BoundingBox() (r Rect) {
+	r = EmptyRect
+	for _, s := range p.segments() {
+		r = r.Union(s.bounds())
+	}
+	return r
+}
+
+// Get the bounding box of the curves of p. The box is kept with the
+// value of p.D it was computed from, and is computed again when p.D
+// has changed, so any edit of p.D makes it be computed again. It may
+// be called from several goroutines at once.
+func (p *Path) BoundingBox() (r Rect, err error) {
+	if b, ok := p.box.Load().(pathBox); ok && b.d == p.D {
+		return b.r, nil
+	}
+	path, err := p.Parse()
+	if err != nil {
+		return EmptyRect, err
+	}
+	r = path.BoundingBox()
+	p.box.Store(pathBox{p.D, r})
+	return r, nil
+}
+
+// Get the bounding box of all the paths in g and its subgroups.
+func (g *Group) BoundingBox() (r Rect, err error) {
+	return boundingBox(g.GetPaths())
+}
+
+// Get the bounding box of all the strokes of the kanji.
+func (svg *SVG) BoundingBox() (r Rect, err error) {
+	base, err := svg.FindBaseGroup()
+	if err != nil {
+		return EmptyRect, err
+	}
+	return base.BoundingBox()
+}
+
+// The union of the bounding boxes of paths.
+func boundingBox(paths []*Path) (r Rect, err error) {
+	r = EmptyRect
+	for _, p := range paths {
+		b, err := p.BoundingBox()
+		if err != nil {
+			return EmptyRect, err
+		}
+		r = r.Union(b)
+	}
+	return r, nil
+}
+
+// Get the rectangle given by the viewBox attribute of svg, which is
+// "0 0 109 109" in the KanjiVG files.
+func (svg *SVG) ViewBoxRect() (r Rect, err error) {
+	fields := strings.FieldsFunc(svg.ViewBox, func(c rune) bool {
+		return c == ' ' || c == ',' || c == '\t' || c == '\n' || c == '\r'
+	})
+	if len(fields) != 4 {
+		return EmptyRect, &Error{Op: "ViewBoxRect", Name: svg.ViewBox, Kind: ErrBadViewBox}
+	}
+	var v [4]float64
+	for i, f := range fields {
+		v[i], err = strconv.ParseFloat(f, 64)
+		if err != nil {
+			return EmptyRect, &Error{Op: "ViewBoxRect", Name: svg.ViewBox, Kind: ErrBadViewBox,
+				Err: err}
+		}
+	}
+	return Rect{Point{v[0], v[1]}, Point{v[0] + v[2], v[1] + v[3]}}, nil
+}
@@ -0,0 +1,79 @@
+package kvg
+
+import (
+	"errors"
+	"sync"
+	"testing"
+)
+
+func TestBoundingBox(t *testing.T) {
+	var p Path
+	p.D = "M0,0C0,10,10,10,10,0"
+	r, err := p.BoundingBox()
+	if err != nil {
+		t.Fatalf("Error getting box of %s: %s", p.D, err)
+	}
+	if r != (Rect{Point{0, 0}, Point{10, 7.5}}) {
+		t.Errorf("Box of %s is %s", p.D, r)
+	}
+	p.D = "M5,5q5,10,10,0"
+	r, _ = p.BoundingBox()
+	if r != (Rect{Point{5, 5}, Point{15, 10}}) {
+		t.Errorf("Box after editing to %s is %s", p.D, r)
+	}
+	p.Apply(Translate(1, 2))
+	r, _ = p.BoundingBox()
+	if r != (Rect{Point{6, 7}, Point{16, 12}}) {
+		t.Errorf("Box after moving to %s is %s", p.D, r)
+	}
+	// The box of the new D is computed by several goroutines at once.
+	p.D = "M0,0h4"
+	r = Rect{Point{0, 0}, Point{4, 0}}
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if b, _ := p.BoundingBox(); b != r {
+				t.Errorf("Box of %s is %s from another goroutine", p.D, b)
+			}
+		}()
+	}
+	wg.Wait()
+	svg, err := ReadKanjiFile(bin() + "/t/08475.svg")
+	if err != nil {
+		t.Fatalf("Error reading: %s", err)
+	}
+	view, err := svg.ViewBoxRect()
+	if err != nil {
+		t.Fatalf("Error getting view box: %s", err)
+	}
+	bad := SVG{ViewBox: "0 0 109 x"}
+	if _, err := bad.ViewBoxRect(); !errors.Is(err, ErrBadViewBox) {
+		t.Errorf("Expected ErrBadViewBox, got %v", err)
+	}
+	all, err := svg.BoundingBox()
+	if err != nil || all.Empty() || !view.Contains(all) {
+		t.Errorf("Box of kanji %s not inside %s: %v", all, view, err)
+	}
+	for _, c := range svg.BaseGroup().Children {
+		if !c.IsGroup {
+			continue
+		}
+		g := &c.Group
+		box, _ := g.BoundingBox()
+		if !all.Contains(box) {
+			t.Errorf("Box of %s %s not inside %s", g.ID, box, all)
+		}
+		for _, p := range g.GetPaths() {
+			pbox, _ := p.BoundingBox()
+			if !box.Contains(pbox) {
+				t.Errorf("Box of %s %s not inside %s", p.ID, pbox, box)
+			}
+		}
+	}
+	var empty Group
+	if r, _ := empty.BoundingBox(); !r.Empty() {
+		t.Errorf("Box of empty group is %s", r)
+	}
+}
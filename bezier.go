@@ -0,0 +1,267 @@
+package kvg
+
+import "math"
+
+// Evaluating, flattening and resampling the curves of paths.
+
+// A point in the coordinates of the SVG, or a vector such as a
+// tangent.
+type Point struct {
+	X, Y float64
+}
+
+func (p Point) add(q Point) Point {
+	return Point{p.X + q.X, p.Y + q.Y}
+}
+
+func (p Point) sub(q Point) Point {
+	return Point{p.X - q.X, p.Y - q.Y}
+}
+
+func (p Point) scale(k float64) Point {
+	return Point{p.X * k, p.Y * k}
+}
+
+func (p Point) length() float64 {
+	return math.Hypot(p.X, p.Y)
+}
+
+// Get p scaled to length one, or the zero vector if p is zero.
+func (p Point) unit() Point {
+	l := p.length()
+	if l == 0 {
+		return Point{}
+	}
+	return p.scale(1 / l)
+}
+
+// One Bézier curve of a path. The control points are p[0] to p[n],
+// where n is 3 for a cubic and 2 for a quadratic.
+type segment struct {
+	p [4]Point
+	n int
+}
+
+// The curves of p, in order, from the expanded form of p. The moves
+// between subpaths are not included.
+func (p SVGPath) segments() (segs []segment) {
+	var at Point
+	for _, s := range p.Expand().Subpaths {
+		for _, c := range s.Commands {
+			q := c.Params
+			switch c.Symbol {
+			case "M":
+				at = Point{q[0], q[1]}
+			case "C":
+				segs = append(segs, segment{[4]Point{at, {q[0], q[1]},
+					{q[2], q[3]}, {q[4], q[5]}}, 3})
+				at = Point{q[4], q[5]}
+			case "Q":
+				segs = append(segs, segment{[4]Point{at, {q[0], q[1]},
+					{q[2], q[3]}}, 2})
+				at = Point{q[2], q[3]}
+			}
+		}
+	}
+	return segs
+}
+
+// The last point of s.
+func (s segment) end() Point {
+	return s.p[s.n]
+}
+
+// Split s at t into two curves of the same degree, using de
+// Casteljau's algorithm.
+func (s segment) split(t float64) (a, b segment) {
+	a.n, b.n = s.n, s.n
+	q := s.p
+	for i := 0; i <= s.n; i++ {
+		a.p[i] = q[0]
+		b.p[s.n-i] = q[s.n-i]
+		for j := 0; j < s.n-i; j++ {
+			q[j] = q[j].add(q[j+1].sub(q[j]).scale(t))
+		}
+	}
+	return a, b
+}
+
+// The point of s at t, between 0 and 1.
+func (s segment) point(t float64) Point {
+	a, _ := s.split(t)
+	return a.end()
+}
+
+// The derivative of s, which is a curve of one degree less through
+// the differences of the control points.
+func (s segment) hodograph() (d segment) {
+	d.n = s.n - 1
+	for i := 0; i < s.n; i++ {
+		d.p[i] = s.p[i+1].sub(s.p[i]).scale(float64(s.n))
+	}
+	return d
+}
+
+// The derivative of s at t.
+func (s segment) derivative(t float64) Point {
+	return s.hodograph().point(t)
+}
+
+// The direction of s at t, as a vector of length one. Where the
+// derivative is zero, such as at the end of a curve whose control
+// point is on the end point, the direction of the curve near t is
+// used instead.
+func (s segment) tangent(t float64) Point {
+	if d := s.derivative(t).unit(); d != (Point{}) {
+		return d
+	}
+	// At the ends, this is the direction to the nearest control
+	// point which is not on the end.
+	switch t {
+	case 0:
+		for _, c := range s.p[1 : s.n+1] {
+			if c != s.p[0] {
+				return c.sub(s.p[0]).unit()
+			}
+		}
+	case 1:
+		for i := s.n - 1; i >= 0; i-- {
+			if s.p[i] != s.end() {
+				return s.end().sub(s.p[i]).unit()
+			}
+		}
+	}
+	const h = 1e-6
+	return s.point(math.Min(t+h, 1)).sub(s.point(math.Max(t-h, 0))).unit()
+}
+
+// Is s within tolerance of the straight line between its ends?
+func (s segment) flat(tolerance float64) bool {
+	a, b := s.p[0], s.end()
+	chord := b.sub(a)
+	l := chord.length()
+	for _, c := range s.p[1:s.n] {
+		v := c.sub(a)
+		var d float64
+		if l == 0 {
+			d = v.length()
+		} else {
+			d = math.Abs(chord.X*v.Y-chord.Y*v.X) / l
+		}
+		if d > tolerance {
+			return false
+		}
+	}
+	return true
+}
+
+// Append to out the points after the start of a polyline within
+// tolerance of s.
+func (s segment) flatten(tolerance float64, out []Point, depth int) []Point {
+	if depth >= 12 || s.flat(tolerance) {
+		return append(out, s.end())
+	}
+	a, b := s.split(0.5)
+	out = a.flatten(tolerance, out, depth+1)
+	return b.flatten(tolerance, out, depth+1)
+}
+
+// Get the point and the direction of p at t, which goes from 0 at the
+// start of the path to 1 at its end. Each curve of the expanded path
+// takes an equal part of the range, so with two curves, t of 0.5 is
+// the end of the first. The tangent is a vector of length one, or the
+// zero vector for a path with no curves.
+func (p SVGPath) PointAt(t float64) (point, tangent Point) {
+	segs := p.segments()
+	if len(segs) == 0 {
+		return point, tangent
+	}
+	t = math.Max(0, math.Min(1, t)) * float64(len(segs))
+	i := int(t)
+	if i == len(segs) {
+		i--
+	}
+	s := segs[i]
+	return s.point(t - float64(i)), s.tangent(t - float64(i))
+}
+
+// Make p into a polyline which is nowhere further than tolerance from
+// the curves of p. Where p has more than one subpath, the polylines
+// are joined together.
+func (p SVGPath) Flatten(tolerance float64) (points []Point) {
+	for i, s := range p.segments() {
+		if i == 0 || points[len(points)-1] != s.p[0] {
+			points = append(points, s.p[0])
+		}
+		points = s.flatten(tolerance, points, 0)
+	}
+	return points
+}
+
+// Get n points spaced equally along p by arc length, starting at the
+// start of p and ending at its end. The lengths are measured along
+// the polyline made by Flatten with the given tolerance.
+func (p SVGPath) Resample(n int, tolerance float64) (points []Point) {
+	line := p.Flatten(tolerance)
+	if n <= 0 || len(line) == 0 {
+		return nil
+	}
+	if n == 1 || len(line) == 1 {
+		for i := 0; i < n; i++ {
+			points = append(points, line[0])
+		}
+		return points
+	}
+	// The distance along the polyline of each of its points.
+	dist := make([]float64, len(line))
+	for i := 1; i < len(line); i++ {
+		dist[i] = dist[i-1] + line[i].sub(line[i-1]).length()
+	}
+	total := dist[len(dist)-1]
+	j := 0
+	for i := 0; i < n-1; i++ {
+		want := total * float64(i) / float64(n-1)
+		for j < len(line)-2 && dist[j+1] < want {
+			j++
+		}
+		var f float64
+		if l := dist[j+1] - dist[j]; l > 0 {
+			f = (want - dist[j]) / l
+		}
+		points = append(points, line[j].add(line[j+1].sub(line[j]).scale(f)))
+	}
+	return append(points, line[len(line)-1])
+}
+
+// Parse the 'd' attribute of p.
+func (p *Path) Parse() (SVGPath, error) {
+	return PathParser(p.D)
+}
+
+// Get the point and direction of p at t. See SVGPath.PointAt.
+func (p *Path) PointAt(t float64) (point, tangent Point, err error) {
+	path, err := p.Parse()
+	if err != nil {
+		return point, tangent, err
+	}
+	point, tangent = path.PointAt(t)
+	return point, tangent, nil
+}
+
+// Make p into a polyline. See SVGPath.Flatten.
+func (p *Path) Flatten(tolerance float64) (points []Point, err error) {
+	path, err := p.Parse()
+	if err != nil {
+		return nil, err
+	}
+	return path.Flatten(tolerance), nil
+}
+
+// Get n points spaced equally along p. See SVGPath.Resample.
+func (p *Path) Resample(n int, tolerance float64) (points []Point, err error) {
+	path, err := p.Parse()
+	if err != nil {
+		return nil, err
+	}
+	return path.Resample(n, tolerance), nil
+}
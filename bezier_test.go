@@ -0,0 +1,76 @@
+package kvg
+
+import (
+	"math"
+	"testing"
+)
+
+func near(a, b Point) bool {
+	return a.sub(b).length() < 1e-9
+}
+
+func TestBezier(t *testing.T) {
+	path, _ := PathParser("M0,0h10q10,0,10,10")
+	point, tangent := path.PointAt(0.25)
+	if !near(point, Point{5, 0}) || !near(tangent, Point{1, 0}) {
+		t.Errorf("Bad point %v or tangent %v", point, tangent)
+	}
+	point, tangent = path.PointAt(1)
+	if !near(point, Point{20, 10}) || !near(tangent, Point{0, 1}) {
+		t.Errorf("Bad point %v or tangent %v at end", point, tangent)
+	}
+	// A curve whose first control point is its start.
+	path, _ = PathParser("M0,0C0,0,10,10,10,20")
+	_, tangent = path.PointAt(0)
+	if !near(tangent, Point{1, 1}.unit()) {
+		t.Errorf("Bad tangent %v at cusp", tangent)
+	}
+	path, _ = PathParser("M0,0h10v10")
+	points := path.Resample(5, 0.01)
+	want := []Point{{0, 0}, {5, 0}, {10, 0}, {10, 5}, {10, 10}}
+	for i := range want {
+		if len(points) != len(want) || !near(points[i], want[i]) {
+			t.Fatalf("Resampled %v, expected %v", points, want)
+		}
+	}
+	svg, err := ReadKanjiFile(bin() + "/t/08475.svg")
+	if err != nil {
+		t.Fatalf("Error reading: %s", err)
+	}
+	const tolerance = 0.01
+	for _, p := range svg.GetPaths() {
+		line, err := p.Flatten(tolerance)
+		if err != nil {
+			t.Fatalf("Error flattening %s: %s", p.D, err)
+		}
+		path, _ := p.Parse()
+		// The middle of each piece of the polyline is close to the
+		// curve.
+		for i := 1; i < len(line); i++ {
+			mid := line[i-1].add(line[i]).scale(0.5)
+			best, at := math.Inf(1), 0.0
+			for k := 0; k <= 1000; k++ {
+				q, _ := path.PointAt(float64(k) / 1000)
+				if d := q.sub(mid).length(); d < best {
+					best, at = d, float64(k)/1000
+				}
+			}
+			for k := -100; k <= 100; k++ {
+				q, _ := path.PointAt(at + float64(k)/100000)
+				best = math.Min(best, q.sub(mid).length())
+			}
+			if best > tolerance {
+				t.Errorf("%s: %v is %g from the curve", p.ID, mid, best)
+			}
+		}
+		points, _ := p.Resample(10, tolerance)
+		if len(points) != 10 {
+			t.Fatalf("%s: resampled to %d points", p.ID, len(points))
+		}
+		start, _ := path.PointAt(0)
+		end, _ := path.PointAt(1)
+		if !near(points[0], start) || !near(points[9], end) {
+			t.Errorf("%s: resampled from %v to %v", p.ID, points[0], points[9])
+		}
+	}
+}
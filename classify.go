@@ -0,0 +1,289 @@
+package kvg
+
+import (
+	"math"
+	"sort"
+	"strings"
+)
+
+// Guessing the kvg:type of a stroke from its shape.
+//
+// A stroke is divided into pieces at its sharp corners, and the
+// directions of the pieces are compared with those of each stroke
+// type. For example ㇕ (HZ) is a piece to the right and then a piece
+// downwards.
+
+// The directions of the pieces of each stroke type, and the range of
+// lengths for the types which are told apart by their length. The
+// direction of the first piece is the direction in which the stroke
+// type sets off, from strokeTypes, turned by bend for the types whose
+// first piece curves away from it, such as ㇓ (SP).
+type strokeShape struct {
+	bend Direction
+	// The directions of the pieces after the first.
+	then []Direction
+	// Whether the last piece is a short hook, as in the types whose
+	// names end in G (钩) or T (提).
+	hook bool
+	// The shortest and longest the stroke may be, or zero for no
+	// limit.
+	minLength, maxLength float64
+}
+
+var strokeShapes = map[rune]strokeShape{
+	'㇀': {},
+	'㇁': {then: []Direction{-135}, hook: true},
+	'㇂': {then: []Direction{-90}, hook: true},
+	'㇃': {bend: -15, then: []Direction{-120}, hook: true},
+	'㇄': {then: []Direction{0}},
+	'㇅': {then: []Direction{90, 0}},
+	'㇆': {then: []Direction{100, -135}, hook: true},
+	'㇇': {then: []Direction{125}},
+	'㇈': {then: []Direction{110, 0, -90}, hook: true},
+	'㇉': {then: []Direction{0, 90, -135}, hook: true},
+	'㇊': {then: []Direction{90, -45}, hook: true},
+	'㇋': {then: []Direction{110, 0, 120}},
+	'㇌': {then: []Direction{120, 90, -135}, hook: true},
+	'㇍': {then: []Direction{90, 0}},
+	'㇎': {then: []Direction{90, 0, 90}},
+	'㇏': {minLength: 22},
+	'㇐': {},
+	'㇑': {},
+	'㇒': {},
+	'㇓': {bend: 20},
+	'㇔': {maxLength: 22},
+	'㇕': {then: []Direction{90}},
+	'㇖': {then: []Direction{135}, hook: true},
+	'㇗': {then: []Direction{0}},
+	'㇘': {then: []Direction{180}},
+	'㇙': {then: []Direction{-45}, hook: true},
+	'㇚': {then: []Direction{-135}, hook: true},
+	'㇛': {then: []Direction{30}},
+	'㇜': {then: []Direction{-10}},
+	'㇝': {minLength: 22},
+	'㇞': {then: []Direction{0, 90}},
+	'㇟': {then: []Direction{0, -90}, hook: true},
+	'㇠': {then: []Direction{70, -90}, hook: true},
+	'㇡': {then: []Direction{100, 0, 100, -135}, hook: true},
+	'㇢': {then: []Direction{-90}, hook: true},
+}
+
+// Get the directions of all the pieces of the stroke type r.
+func shapePieces(r rune) []Direction {
+	shape := strokeShapes[r]
+	return append([]Direction{strokeTypes[r].start + shape.bend}, shape.then...)
+}
+
+// The stroke types which have the same shape to the classifier, so
+// that no path fits one better than the others. ㇄ (SW) and ㇗ (SZ)
+// differ only in whether the corner is curved, and ㇅ (HZZ) and ㇍
+// (HZW) likewise. Each of these groups has a single guess, whose type
+// lists the group with slashes as in the kvg:type values, such as
+// "㇄/㇗".
+var sameShapes = [][]rune{
+	{'㇄', '㇗'},
+	{'㇅', '㇍'},
+}
+
+const (
+	// The number of points a stroke is resampled to before looking
+	// for corners.
+	classifySamples = 64
+	// The number of points either side of a point over which the
+	// turn at the point is measured.
+	cornerWindow = 3
+	// The least turn in degrees which is a corner.
+	cornerAngle = 55
+	// How much the score goes down for each piece too many or too
+	// few.
+	piecePenalty = 0.25
+	// How much the score goes down if the stroke is too long or too
+	// short for the type, or its hook is too long.
+	lengthPenalty = 0.3
+	// The longest a hook may be, as a fraction of the stroke.
+	hookLength = 0.3
+)
+
+// The shape of a stroke as used by the classifier.
+type strokeFeatures struct {
+	// The directions of the pieces between the corners, and their
+	// lengths as fractions of the length of the stroke.
+	pieces []Direction
+	sizes  []float64
+	// The direction over the first tenth of the stroke.
+	start  Direction
+	length float64
+}
+
+// Find the pieces of p, or false if p has no length.
+func (p SVGPath) features() (f strokeFeatures, ok bool) {
+	d, ok := p.Direction()
+	if !ok {
+		return f, false
+	}
+	f.start = d.Start
+	f.length = p.Metrics(0.01).Length
+	points := p.Resample(classifySamples, 0.01)
+	n := len(points)
+	// The turn in degrees at each point.
+	turn := make([]float64, n)
+	for i := 1; i < n-1; i++ {
+		w := cornerWindow
+		if i < w {
+			w = i
+		}
+		if n-1-i < w {
+			w = n - 1 - i
+		}
+		a, b := points[i].sub(points[i-w]), points[i+w].sub(points[i])
+		turn[i] = math.Abs(angle(a.X, a.Y, b.X, b.Y)) * 180 / math.Pi
+	}
+	// A corner is the point of greatest turn in each run of points
+	// whose turn is at least cornerAngle.
+	corners := []int{0}
+	for i := 1; i < n-1; i++ {
+		if turn[i] < cornerAngle {
+			continue
+		}
+		j := i
+		for j+1 < n-1 && turn[j+1] >= cornerAngle {
+			j++
+		}
+		best := i
+		for k := i; k <= j; k++ {
+			if turn[k] > turn[best] {
+				best = k
+			}
+		}
+		corners = append(corners, best)
+		i = j
+	}
+	corners = append(corners, n-1)
+	for i := 1; i < len(corners); i++ {
+		v := points[corners[i]].sub(points[corners[i-1]])
+		if v == (Point{}) {
+			continue
+		}
+		f.pieces = append(f.pieces, direction(v))
+		f.sizes = append(f.sizes, float64(corners[i]-corners[i-1])/float64(n-1))
+	}
+	return f, true
+}
+
+// How well f fits the shape of the stroke type r, which is near 1 for
+// a good fit and lower for a worse one.
+func (f strokeFeatures) score(r rune) float64 {
+	shape := strokeShapes[r]
+	pieces := shapePieces(r)
+	n := len(pieces)
+	if len(f.pieces) < n {
+		n = len(f.pieces)
+	}
+	var fit float64
+	for i := 0; i < n; i++ {
+		fit += f.pieces[i].agree(pieces[i])
+	}
+	if n > 0 {
+		fit /= float64(n)
+	}
+	fit -= piecePenalty * math.Abs(float64(len(f.pieces)-len(pieces)))
+	s := 0.8*fit + 0.2*f.start.agree(strokeTypes[r].start)
+	if shape.minLength > 0 && f.length < shape.minLength ||
+		shape.maxLength > 0 && f.length > shape.maxLength {
+		s -= lengthPenalty
+	}
+	if shape.hook && len(f.sizes) > 1 && f.sizes[len(f.sizes)-1] > hookLength {
+		s -= lengthPenalty
+	}
+	return s
+}
+
+// A possible stroke type of a path, with how well the path fits it.
+type StrokeGuess struct {
+	// A CJK stroke character, such as "㇐", or for the types which
+	// have the same shape, the characters separated by slashes, such
+	// as "㇄/㇗".
+	Type  string
+	Score float64
+}
+
+// Does the kvg:type kvgType fit g? It fits if any of its alternatives
+// is one of the types of g, ignoring the variant letters, so "㇗a" and
+// "㇔/㇗" both fit the guess "㇄/㇗".
+func (g StrokeGuess) Fits(kvgType string) bool {
+	for _, r := range StrokeTypes(kvgType) {
+		for _, s := range StrokeTypes(g.Type) {
+			if r == s {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Guess the kvg:type of p from its shape alone. The guesses are for
+// each stroke type of the CJK Strokes block except ㇣, best first, with
+// the types of each of sameShapes in a single guess. The guesses do
+// not have variant letters, since the variants are not told apart by
+// their shape; use StrokeGuess.Fits or TypeFits to compare them with
+// a kvg:type. A path with no length has no guesses.
+func (p SVGPath) ClassifyStroke() (guesses []StrokeGuess) {
+	f, ok := p.features()
+	if !ok {
+		return nil
+	}
+	grouped := map[rune]bool{}
+	for _, same := range sameShapes {
+		guesses = append(guesses, StrokeGuess{joinTypes(same), f.score(same[0])})
+		for _, r := range same {
+			grouped[r] = true
+		}
+	}
+	for r := range strokeShapes {
+		if !grouped[r] {
+			guesses = append(guesses, StrokeGuess{string(r), f.score(r)})
+		}
+	}
+	sort.Slice(guesses, func(i, j int) bool {
+		if guesses[i].Score != guesses[j].Score {
+			return guesses[i].Score > guesses[j].Score
+		}
+		return guesses[i].Type < guesses[j].Type
+	})
+	return guesses
+}
+
+// Write the stroke types types with slashes between them.
+func joinTypes(types []rune) string {
+	var s []string
+	for _, r := range types {
+		s = append(s, string(r))
+	}
+	return strings.Join(s, "/")
+}
+
+// Guess the kvg:type of p. See SVGPath.ClassifyStroke.
+func (p *Path) ClassifyStroke() (guesses []StrokeGuess, err error) {
+	path, err := p.Parse()
+	if err != nil {
+		return nil, err
+	}
+	return path.ClassifyStroke(), nil
+}
+
+// Does the kvg:type kvgType fit the guesses? It fits if the score of
+// any guess which it fits is no more than margin below the best
+// guess, so "㇔/㇀" fits a stroke which looks like either, and "㇑a"
+// fits one which looks like ㇑. A type which is not of the CJK
+// Strokes block, or no guesses, always fits.
+func TypeFits(kvgType string, guesses []StrokeGuess, margin float64) bool {
+	if len(StrokeTypes(kvgType)) == 0 || len(guesses) == 0 {
+		return true
+	}
+	for _, g := range guesses {
+		if g.Fits(kvgType) && g.Score >= guesses[0].Score-margin {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,93 @@
+package kvg
+
+import "testing"
+
+func TestClassifyStroke(t *testing.T) {
+	svg, err := ReadKanjiFile(bin() + "/t/08475.svg")
+	if err != nil {
+		t.Fatalf("Error reading: %s", err)
+	}
+	paths := svg.GetPaths()
+	for _, p := range paths {
+		guesses, err := p.ClassifyStroke()
+		if err != nil {
+			t.Fatalf("Error classifying %s: %s", p.ID, err)
+		}
+		if !TypeFits(p.Type, guesses, 0.1) {
+			t.Errorf("%s of type %s looks like %v", p.ID, p.Type, guesses[:3])
+		}
+	}
+	// The types of the horizontal s1 and the vertical s2 swapped by
+	// mistake.
+	guesses, _ := paths[0].ClassifyStroke()
+	if TypeFits(paths[1].Type, guesses, 0.1) {
+		t.Errorf("%s fits type %s", paths[0].ID, paths[1].Type)
+	}
+	tests := []struct {
+		d, want string
+	}{
+		{"M10,10h30v30", "㇕"},
+		{"M10,10v30h30", "㇗"},
+		{"M10,10l8,8", "㇔"},
+		{"M10,10l30,30", "㇏"},
+		{"M10,10h30v30l-5-5", "㇆"},
+	}
+	for _, test := range tests {
+		path, _ := PathParser(test.d)
+		guesses := path.ClassifyStroke()
+		if !guesses[0].Fits(test.want) {
+			t.Errorf("%s looks like %v, expected %s", test.d, guesses[:3], test.want)
+		}
+	}
+	if !TypeFits("㇔/㇀", []StrokeGuess{{"㇀", 1}, {"㇔", 0.5}}, 0.1) {
+		t.Errorf("Alternative type does not fit")
+	}
+	if !TypeFits("㇐b", []StrokeGuess{{"㇐", 1}}, 0) {
+		t.Errorf("Variant type does not fit")
+	}
+	g := StrokeGuess{"㇄/㇗", 1}
+	for _, kvgType := range []string{"㇄", "㇗a", "㇔/㇗"} {
+		if !g.Fits(kvgType) {
+			t.Errorf("%s does not fit %s", kvgType, g.Type)
+		}
+	}
+	if g.Fits("㇕") {
+		t.Errorf("㇕ fits %s", g.Type)
+	}
+}
+
+// The types of each group of sameShapes score the same for any path,
+// and have a single guess.
+func TestSameShapes(t *testing.T) {
+	svg, err := ReadKanjiFile(bin() + "/t/08475.svg")
+	if err != nil {
+		t.Fatalf("Error reading: %s", err)
+	}
+	var paths []SVGPath
+	for _, p := range svg.GetPaths() {
+		path, _ := p.Parse()
+		paths = append(paths, path)
+	}
+	for _, d := range []string{"M10,10v30h30", "M10,10h30v30h30", "M10,10q0,30,30,30"} {
+		path, _ := PathParser(d)
+		paths = append(paths, path)
+	}
+	groups := len(strokeShapes)
+	for _, same := range sameShapes {
+		groups -= len(same) - 1
+	}
+	for _, path := range paths {
+		f, _ := path.features()
+		for _, same := range sameShapes {
+			for _, r := range same[1:] {
+				if f.score(r) != f.score(same[0]) {
+					t.Errorf("%s and %s score differently", string(r), string(same[0]))
+				}
+			}
+		}
+		guesses := path.ClassifyStroke()
+		if len(guesses) != groups {
+			t.Errorf("%d guesses, expected %d", len(guesses), groups)
+		}
+	}
+}
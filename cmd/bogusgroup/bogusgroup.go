@@ -3,10 +3,16 @@ package main
 import (
 	"fmt"
 	"kvg"
+	"os"
 )
 
 func main() {
-	kvg.ExamineAllFilesSimple(bogusGroup)
+	corpus := kvg.OpenEnvCorpusOrDie()
+	err := corpus.ExamineAllFilesSimple(bogusGroup)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error examining files: %s\n", err)
+		os.Exit(1)
+	}
 }
 func bogusGroup(file string) {
 	_, base := kvg.Grab(file)
@@ -1,55 +1,78 @@
-// Search all the files for paths which are empty.
+// Search all the files for paths which are empty, and remove them.
+// Removing a path makes the stroke numbers again in step with the
+// paths, so any stroke which is left without a number is given a new
+// one, placed to the left of its start, and this is reported.
 
 package main
 
 import (
 	"fmt"
 	"kvg"
+	"os"
 )
 
+var corpus *kvg.Corpus
+
 func emptyPath(file string) {
-	svg, base := kvg.Grab(file)
+	svg, base, err := corpus.Grab(file)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %s\n", corpus.Rel(file), err)
+		return
+	}
 	paths := base.GetPaths()
-	nums := &svg.Groups[1]
-	nc := len(nums.Children)
+	if len(svg.Groups) < 2 {
+		fmt.Printf("%s: no stroke numbers.\n", corpus.Rel(file))
+		return
+	}
+	nc := len(svg.Labels())
 	if nc == len(paths) {
 		// This file is OK, the number of paths is the same as the number
 		// of stroke labels.
 		return
 	}
 	if nc < len(paths) {
-		fmt.Printf("%s: missing %d numbers.\n", kvg.TFile(file), len(paths)-nc)
+		fmt.Printf("%s: missing %d numbers.\n", corpus.Rel(file), len(paths)-nc)
 	}
 	if nc > len(paths) {
 		// This does not happen for any file.
 		fmt.Printf("%s: too many stroke numbers %d > %d.\n",
-			kvg.TFile(file), nc, len(paths))
+			corpus.Rel(file), nc, len(paths))
 		return
 	}
-	emptyPaths := make([]bool, len(paths))
+	// Remove the empty paths from the end backwards, so that the
+	// positions of the earlier ones don't change. RemoveChild also
+	// keeps the stroke numbers in step with the paths.
 	found := false
-	for i, p := range paths {
-		if i < nc {
+	for i := len(paths) - 1; i >= nc; i-- {
+		p := paths[i]
+		if len(p.D) != 0 {
 			continue
 		}
-		if len(p.D) == 0 {
-			emptyPaths[i] = true
-			found = true
+		_, err = svg.RemoveChild(p.Parent(), p.IndexInParent())
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", corpus.Rel(file), err)
+			return
 		}
+		found = true
 	}
 	if !found {
 		return
 	}
-	newchild := make([]kvg.Child, 0)
-	for i := range nums.Children {
-		if !emptyPaths[i] {
-			newchild = append(newchild, nums.Children[i])
-		}
+	fmt.Printf("%s: removed empty paths\n", corpus.Rel(file))
+	if added := len(svg.Labels()) - nc; added > 0 {
+		fmt.Printf("%s: added %d numbers.\n", corpus.Rel(file), added)
+	}
+	err = svg.Save(file)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %s\n", corpus.Rel(file), err)
 	}
-	nums.Children = newchild
-	fmt.Printf("%s\n", kvg.TFile(file))
 }
 
 func main() {
-	kvg.ExamineAllFilesSimple(emptyPath)
+	corpus = kvg.OpenEnvCorpusOrDie()
+	err := corpus.ExamineAllFilesSimple(emptyPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error examining files: %s\n", err)
+		os.Exit(1)
+	}
 }
@@ -0,0 +1,95 @@
+// Find stroke-number labels which are missing, misplaced or not
+// numbers, and optionally put them right. The exit status is 1 if
+// there are problems which were not put right, or files which could
+// not be checked.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"kvg"
+	"os"
+)
+
+var (
+	corpus *kvg.Corpus
+	// Move the labels which are found.
+	fix bool
+	// The number of problems found.
+	found int
+	// The number of problems which are left after fixing, and the
+	// number of files which could not be checked or fixed.
+	left, failed int
+)
+
+func check(file string) {
+	svg, _, err := corpus.Grab(file)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %s\n", corpus.Rel(file), err)
+		failed++
+		return
+	}
+	errs, err := svg.CheckLabels(corpus.Rel(file), kvg.DefaultLabelPlacement)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err)
+		failed++
+		return
+	}
+	for _, e := range errs {
+		fmt.Printf("%s\n", e)
+	}
+	found += len(errs)
+	if !fix || len(errs) == 0 {
+		left += len(errs)
+		return
+	}
+	err = svg.FixLabels(kvg.DefaultLabelPlacement, errs)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %s\n", corpus.Rel(file), err)
+		failed++
+		return
+	}
+	// Check again, so that labels which could not be put right are
+	// reported.
+	errs, err = svg.CheckLabels(corpus.Rel(file), kvg.DefaultLabelPlacement)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err)
+		failed++
+		return
+	}
+	for _, e := range errs {
+		fmt.Printf("not fixed: %s\n", e)
+	}
+	left += len(errs)
+	err = svg.Save(file)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %s\n", corpus.Rel(file), err)
+		failed++
+	}
+}
+
+func main() {
+	fileFlag := flag.String("file", "", "Check only this file")
+	flag.BoolVar(&fix, "fix", false, "Move the labels which are misplaced")
+	flag.Parse()
+	corpus = kvg.OpenEnvCorpusOrDie()
+	if len(*fileFlag) != 0 {
+		check(corpus.Path(*fileFlag))
+	} else {
+		err := corpus.ExamineAllFilesSimple(check)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error examining files: %s\n", err)
+			os.Exit(1)
+		}
+	}
+	if found > 0 {
+		fmt.Fprintf(os.Stderr, "%d problems with labels\n", found)
+	}
+	if fix && left > 0 {
+		fmt.Fprintf(os.Stderr, "%d problems not fixed\n", left)
+	}
+	if left > 0 || failed > 0 {
+		os.Exit(1)
+	}
+}
@@ -3,9 +3,11 @@ package main
 import (
 	"fmt"
 	"kvg"
-	"strings"
+	"os"
 )
 
+var corpus *kvg.Corpus
+
 func xm(file string) {
 	_, base := kvg.Grab(file)
 	paths := base.GetPaths()
@@ -16,11 +18,15 @@ func xm(file string) {
 		}
 	}
 	if missing {
-		file = strings.Replace(file, "/home/ben/software/kanjivg/kanji/", "", -1)
-		fmt.Printf("%s\n", file)
+		fmt.Printf("%s\n", corpus.Rel(file))
 	}
 }
 
 func main() {
-	kvg.ExamineAllFilesSimple(xm)
+	corpus = kvg.OpenEnvCorpusOrDie()
+	err := corpus.ExamineAllFilesSimple(xm)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error examining files: %s\n", err)
+		os.Exit(1)
+	}
 }
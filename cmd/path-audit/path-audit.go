@@ -0,0 +1,51 @@
+// List the paths of all the files whose d attribute does not follow
+// the grammar of SVG paths.
+
+package main
+
+import (
+	"fmt"
+	"kvg"
+	"os"
+)
+
+var corpus *kvg.Corpus
+
+// The number of paths which could not be parsed.
+var bad int
+
+func audit(file string) {
+	svg, err := kvg.ReadKanjiFile(file)
+	if err != nil {
+		fmt.Printf("%s: %s\n", corpus.Rel(file), err)
+		bad++
+		return
+	}
+	base, err := svg.FindBaseGroup()
+	if err != nil {
+		fmt.Printf("%s: %s\n", corpus.Rel(file), err)
+		bad++
+		return
+	}
+	for _, p := range base.GetPaths() {
+		_, err := kvg.PathParser(p.D)
+		if err == nil {
+			continue
+		}
+		fmt.Printf("%s: %s: %s\n\t%s\n", corpus.Rel(file), p.ID, err, p.D)
+		bad++
+	}
+}
+
+func main() {
+	corpus = kvg.OpenEnvCorpusOrDie()
+	err := corpus.ExamineAllFilesSimple(audit)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error examining files: %s\n", err)
+		os.Exit(1)
+	}
+	if bad > 0 {
+		fmt.Fprintf(os.Stderr, "%d bad paths\n", bad)
+		os.Exit(1)
+	}
+}
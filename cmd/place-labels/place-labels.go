@@ -0,0 +1,75 @@
+// Make the stroke-number labels of the files again from nothing,
+// placing each label next to the start of its stroke.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"kvg"
+	"os"
+)
+
+var (
+	corpus *kvg.Corpus
+	// Print the new places of the labels rather than writing them.
+	dryRun bool
+	// The number of files which could not be done.
+	failed int
+)
+
+func place(file string) {
+	svg, _, err := corpus.Grab(file)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %s\n", corpus.Rel(file), err)
+		failed++
+		return
+	}
+	var old []string
+	for _, t := range svg.Labels() {
+		old = append(old, t.Transform.String())
+	}
+	err = svg.PlaceLabels(kvg.DefaultLabelPlacement)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %s\n", corpus.Rel(file), err)
+		failed++
+		return
+	}
+	if !dryRun {
+		err = svg.Save(file)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", corpus.Rel(file), err)
+			failed++
+		}
+		return
+	}
+	for i, t := range svg.Labels() {
+		was := "none"
+		if i < len(old) {
+			was = old[i]
+		}
+		fmt.Printf("%s: %s: %s -> %s\n", corpus.Rel(file), t.Content, was, t.Transform)
+	}
+	for i := len(svg.Labels()); i < len(old); i++ {
+		fmt.Printf("%s: removed %s\n", corpus.Rel(file), old[i])
+	}
+}
+
+func main() {
+	fileFlag := flag.String("file", "", "Place the labels of only this file")
+	flag.BoolVar(&dryRun, "dry-run", false, "Print the new places of the labels without changing the files")
+	flag.Parse()
+	corpus = kvg.OpenEnvCorpusOrDie()
+	if len(*fileFlag) != 0 {
+		place(corpus.Path(*fileFlag))
+	} else {
+		err := corpus.ExamineAllFilesSimple(place)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error examining files: %s\n", err)
+			os.Exit(1)
+		}
+	}
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
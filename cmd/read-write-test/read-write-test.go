@@ -11,6 +11,14 @@
 
    If the --verbose flag is supplied, a progress message is printed.
 
+   If the --normalize flag is supplied, the radicals of the variant
+   files of a kanji are compared after normalizing them, so that for
+   example ⺡ and 氵 are counted as the same radical.
+
+   The files are checked in parallel, using the number of goroutines
+   given by --workers, but the output is printed in the order of the
+   file names.
+
    This was one of the first things I wrote using the kvg library, and
    thus some of the methods used predate better methods I invented
    later after getting experience with the library. Thus this file may
@@ -20,14 +28,16 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
-	"io/fs"
+	"io"
 	"io/ioutil"
 	"kvg"
 	"os"
-	"path/filepath"
-	"strings"
+	"sort"
+	"sync"
+	"sync/atomic"
 )
 
 // True if c is whitespace.
@@ -35,15 +45,15 @@ func white(c byte) bool {
 	return c == ' ' || c == '\t' || c == '\n'
 }
 
-// Compare two xmls and print the first difference to stdout.
-func compareXML(file string, xmlout, xmlin []byte) {
+// Compare two xmls and print the first difference to out.
+func compareXML(out io.Writer, file string, xmlout, xmlin []byte) {
 	fileprinted := false
 	no := len(xmlout)
 	ni := len(xmlin)
 	if no != ni {
-		fmt.Printf("%s:\n", file)
+		fmt.Fprintf(out, "%s:\n", file)
 		fileprinted = true
-		fmt.Printf("Lengths differ (original %d - formatted %d bytes).\n",
+		fmt.Fprintf(out, "Lengths differ (original %d - formatted %d bytes).\n",
 			no, ni)
 	}
 	n := ni
@@ -59,25 +69,25 @@ func compareXML(file string, xmlout, xmlin []byte) {
 			break
 		}
 		if xmlout[i] != c {
-			totalFails++
+			atomic.AddInt64(&totalFails, 1)
 			if white(xmlout[i]) || white(c) {
-				fmt.Printf("%s: Whitespace difference.\n", file)
-				whiteFails++
+				fmt.Fprintf(out, "%s: Whitespace difference.\n", file)
+				atomic.AddInt64(&whiteFails, 1)
 			} else {
-				fmt.Printf("%s: Attribute or other difference.\n", file)
+				fmt.Fprintf(out, "%s: Attribute or other difference.\n", file)
 			}
 			if !fileprinted {
-				fmt.Printf("%s:\n", file)
+				fmt.Fprintf(out, "%s:\n", file)
 				fileprinted = true
 			}
-			fmt.Printf("First difference at byte %d, line %d, offset %d\n",
+			fmt.Fprintf(out, "First difference at byte %d, line %d, offset %d\n",
 				i, line, offset)
 			start := lineStart
 			end := lineStart + offset + 40
 			if end > n {
 				end = n
 			}
-			fmt.Printf("IN:  *%s*\nOUT: *%s*\n", xmlin[start:end], xmlout[start:end])
+			fmt.Fprintf(out, "IN:  *%s*\nOUT: *%s*\n", xmlin[start:end], xmlout[start:end])
 			if fix {
 				// Write the file back out
 				err := ioutil.WriteFile(file, xmlout, 0644)
@@ -99,9 +109,32 @@ func compareXML(file string, xmlout, xmlin []byte) {
 
 var kanjiRad map[rune]map[string]string
 
+// The elements of the radicals of one file, kept until all the files
+// have been read so that the variant files can be compared in order.
+type fileRadicals struct {
+	file  string
+	kanji rune
+	rad   map[string][]string
+}
+
+var radMutex sync.Mutex
+var allRadicals []fileRadicals
+
+// Get the elements of the groups in gs.
+func elements(gs []*kvg.Group) (els []string) {
+	for _, g := range gs {
+		el := g.El()
+		if normalize {
+			el = kvg.CanonicalElement(el)
+		}
+		els = append(els, el)
+	}
+	return els
+}
+
 // Check that the radical in this variant file is the same as the
 // radicals in the other variants of the same kanji.
-func checkABoo(file string, kanji rune, what string, gs []*kvg.Group) {
+func checkABoo(file string, kanji rune, what string, gs []string) {
 	if len(gs) == 0 {
 		// This radical is not present in the file.
 		return
@@ -116,11 +149,10 @@ func checkABoo(file string, kanji rune, what string, gs []*kvg.Group) {
 			// "kanji".
 			kanjiRad[kanji] = make(map[string]string, 0)
 		}
-		kanjiRad[kanji][what] = gs[0].El()
+		kanjiRad[kanji][what] = gs[0]
 		return
 	}
-	for _, g := range gs {
-		el := g.El()
+	for _, el := range gs {
 		if el != gen {
 			fmt.Printf("%s: %s radical does not match other variant files '%s' (%X) != '%s' (%X)\n",
 				file, what, el, []rune(el)[0], gen, []rune(gen)[0])
@@ -130,15 +162,14 @@ func checkABoo(file string, kanji rune, what string, gs []*kvg.Group) {
 
 // Check that the radicals of each type are the same between the
 // variant files for each kanji.
-func checkSame(file string, kanji rune, rad kvg.Radical) {
-	checkABoo(file, kanji, "general", rad.General)
-	checkABoo(file, kanji, "nelson", rad.Nelson)
-	checkABoo(file, kanji, "tradit", rad.Tradit)
-	checkABoo(file, kanji, "jis", rad.JIS)
+func checkSame(fr fileRadicals) {
+	for _, what := range []string{"general", "nelson", "tradit", "jis"} {
+		checkABoo(fr.file, fr.kanji, what, fr.rad[what])
+	}
 }
 
 // Check that the radicals are consistent and present.
-func checkRadical(file string, svg *kvg.SVG, base *kvg.Group, kanji rune) {
+func checkRadical(out io.Writer, file string, svg *kvg.SVG, base *kvg.Group, kanji rune) {
 	if !kvg.ExpectRadical(kanji) {
 		return
 	}
@@ -147,34 +178,55 @@ func checkRadical(file string, svg *kvg.SVG, base *kvg.Group, kanji rune) {
 	// Check there is at least one radical in the file.
 	if len(rad.General) == 0 && len(rad.Tradit) == 0 &&
 		len(rad.Nelson) == 0 && len(rad.JIS) == 0 {
-		fmt.Printf("No radical found in %s\n", file)
-		totalFails++
+		fmt.Fprintf(out, "No radical found in %s\n", file)
+		atomic.AddInt64(&totalFails, 1)
 	}
 	// Check that, if there is a Nelson radical, then there must also
 	// be a traditional radical which it differs from.
 	if len(rad.Nelson) > 0 && len(rad.Tradit) == 0 {
-		fmt.Printf("Inconsistent radicals: Nelson, no Tradit in %s\n", file)
-		totalFails++
+		fmt.Fprintf(out, "Inconsistent radicals: Nelson, no Tradit in %s\n", file)
+		atomic.AddInt64(&totalFails, 1)
 	}
 	// It might be useful to do checks that the JIS radical alone is
 	// not present in a similar way to the above, although there are
 	// so few examples of the JIS radicals that it's not currently a
 	// priority.
-	checkSame(file, kanji, rad)
+	fr := fileRadicals{file: file, kanji: kanji, rad: map[string][]string{
+		"general": elements(rad.General),
+		"nelson":  elements(rad.Nelson),
+		"tradit":  elements(rad.Tradit),
+		"jis":     elements(rad.JIS),
+	}}
+	radMutex.Lock()
+	allRadicals = append(allRadicals, fr)
+	radMutex.Unlock()
 }
 
-// Check the format of the specified file.
-func readWriteTest(file string) {
-	contents, oerr := ioutil.ReadFile(file)
-	if oerr != nil {
-		fmt.Fprintf(os.Stderr, "Error opening %s: %s\n", file, oerr)
-		os.Exit(1)
-	}
-	svg, err := kvg.ParseKanji(contents)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Too bad %s\n", err)
-		os.Exit(1)
+// Check that the d attribute of each path comes back the same after
+// parsing it and writing it out again.
+func checkPaths(out io.Writer, file string, base *kvg.Group) {
+	for _, p := range base.GetPaths() {
+		path, err := kvg.PathParser(p.D)
+		if err != nil {
+			fmt.Fprintf(out, "%s: %s: error parsing path: %s\n", file, p.ID, err)
+			atomic.AddInt64(&totalFails, 1)
+			continue
+		}
+		d := path.String()
+		if d != p.D {
+			fmt.Fprintf(out, "%s: %s: path does not round trip:\nIN:  %s\nOUT: %s\n",
+				file, p.ID, p.D, d)
+			atomic.AddInt64(&totalFails, 1)
+		}
 	}
+}
+
+// Check the format of the specified file.
+func readWriteTest(f *kvg.WalkFile) error {
+	file := f.Name
+	contents := f.Contents
+	svg := f.SVG
+	out := &f.Out
 	id, kanji, _ := kvg.FileToParts(file)
 	_, base := svg.Base()
 	baseGroup := svg.BaseGroup()
@@ -183,8 +235,8 @@ func readWriteTest(file string) {
 	if len(baseElement) > 0 {
 		baseKanji := []rune(baseElement)[0]
 		if int64(baseKanji) != kanji {
-			abbrevname := strings.Replace(file, kvg.KVDir+"/", "", 1)
-			fmt.Printf("File name, %c, [%s] disagrees with element %s [%05x]\n",
+			abbrevname := corpus.Rel(file)
+			fmt.Fprintf(out, "File name, %c, [%s] disagrees with element %s [%05x]\n",
 				rune(kanji), abbrevname, baseElement, int64(baseKanji))
 			if fix {
 				baseGroup.Element = string([]rune{rune(kanji)})
@@ -192,69 +244,85 @@ func readWriteTest(file string) {
 		}
 	}
 	if svg.Groups[0].ID != "kvg:StrokePaths_"+id {
-		fmt.Printf("StrokePaths id %s != %s\n", svg.Groups[0].ID, id)
-		totalFails++
+		fmt.Fprintf(out, "StrokePaths id %s != %s\n", svg.Groups[0].ID, id)
+		atomic.AddInt64(&totalFails, 1)
 		if fix && !rebased {
 			svg.SetBase("kvg:" + id)
 			rebased = true
 		}
 	}
 	if len(svg.Groups) > 1 && svg.Groups[1].ID != "kvg:StrokeNumbers_"+id {
-		fmt.Printf("StrokeNumbers id %s != %s\n", svg.Groups[1].ID, id)
-		totalFails++
+		fmt.Fprintf(out, "StrokeNumbers id %s != %s\n", svg.Groups[1].ID, id)
+		atomic.AddInt64(&totalFails, 1)
 		if fix && !rebased {
 			svg.SetBase("kvg:" + id)
 			rebased = true
 		}
 	}
 	if id != base {
-		fmt.Printf("Error: base name '%s' and file ID '%s' differ.\n",
+		fmt.Fprintf(out, "Error: base name '%s' and file ID '%s' differ.\n",
 			base, id)
-		totalFails++
+		atomic.AddInt64(&totalFails, 1)
 		if fix && !rebased {
 			svg.SetBase("kvg:" + id)
 			rebased = true
 		}
 	}
-	checkRadical(file, &svg, baseGroup, rune(kanji))
+	checkRadical(out, file, &svg, baseGroup, rune(kanji))
+	for _, e := range svg.CheckValues(corpus.Rel(file)) {
+		fmt.Fprintf(out, "%s\n", e)
+		atomic.AddInt64(&totalFails, 1)
+	}
+	checkPaths(out, file, baseGroup)
 	if len(baseGroup.Position) != 0 {
-		fmt.Printf("%s: base group has silly position %s\n",
+		fmt.Fprintf(out, "%s: base group has silly position %s\n",
 			file, baseGroup.Position)
 		if fix {
 			baseGroup.Position = ""
 		}
-		totalFails++
+		atomic.AddInt64(&totalFails, 1)
 	}
-	svg.RenumberXML()
-	xmlout := svg.MakeXML()
-	compareXML(file, xmlout, contents)
+	xmlout, err := svg.Marshal()
+	if err != nil {
+		return err
+	}
+	compareXML(out, file, xmlout, contents)
+	if verbose {
+		fmt.Fprintf(out, "%d files checked\r", f.Index+1)
+	}
+	return nil
 }
 
+var corpus *kvg.Corpus
 var fix = false
 var verbose = false
-var totalFails = 0
-var whiteFails = 0
+var normalize = false
+var totalFails int64
+var whiteFails int64
 
 func main() {
 	kanjiRad = make(map[rune]map[string]string, 0)
 	fixFlag := flag.Bool("fix", false, "Fix the errors found")
 	verboseFlag := flag.Bool("verbose", false, "Print progress")
+	normalizeFlag := flag.Bool("normalize", false, "Normalize the radicals before comparing them")
+	workersFlag := flag.Int("workers", 0, "Number of files to check at once, default the number of CPUs")
 	flag.Parse()
 	fix = *fixFlag
 	verbose = *verboseFlag
-	n := 0
-	filepath.WalkDir(kvg.KVDir, func(path string, d fs.DirEntry, err error) error {
-		if d.IsDir() {
-			return nil
-		}
-		if kvg.Backup.MatchString(path) {
-			return nil
-		}
-		readWriteTest(path)
-		n++
-		fmt.Printf("%d files checked\r", n)
-		return nil
+	normalize = *normalizeFlag
+	corpus = kvg.OpenEnvCorpusOrDie()
+	opt := kvg.WalkOptions{Workers: *workersFlag, Ordered: true}
+	err := corpus.Walk(context.Background(), opt, readWriteTest)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error examining files:\n%s\n", err)
+		os.Exit(1)
+	}
+	sort.Slice(allRadicals, func(i, j int) bool {
+		return allRadicals[i].file < allRadicals[j].file
 	})
+	for _, fr := range allRadicals {
+		checkSame(fr)
+	}
 	fmt.Println()
 	fmt.Printf("Total failures %d\n", totalFails)
 	fmt.Printf("Whitespace-only inconsistencies %d\n", whiteFails)
@@ -0,0 +1,71 @@
+/* Put the strokes of a file into a new order, with commands of the
+   same form as typeshift:
+
+   reorder-strokes --file 08475.svg --order 6-7=7-8,8=6
+
+   in which strokes 6 and 7 become the old strokes 7 and 8, and stroke
+   8 becomes the old stroke 6. Unlike typeshift, the whole of each stroke moves,
+   with its shape and its label. */
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"kvg"
+	"os"
+)
+
+// Print the strokes of svg in order. If perm is not nil, each stroke
+// is shown with its place before perm.
+func printOrder(svg *kvg.SVG, perm []int) {
+	for i, p := range svg.GetPaths() {
+		was := ""
+		if perm != nil && perm[i] != i {
+			was = fmt.Sprintf(" (was %d)", perm[i]+1)
+		}
+		fmt.Printf("%d: %s %s%s\n", i+1, p.Type, p.D, was)
+	}
+}
+
+func main() {
+	fileFlag := flag.String("file", "", "File to read")
+	orderFlag := flag.String("order", "", "The new order, such as 1-2=3-4,3-4=1-2")
+	dryRun := flag.Bool("dry-run", false, "Print the old and new orders without changing the file")
+	flag.Parse()
+	if len(*fileFlag) == 0 || len(*orderFlag) == 0 {
+		fmt.Fprintf(os.Stderr, "Specify the file with --file <file> and the new order with --order a-b=c-d\n")
+		os.Exit(1)
+	}
+	corpus := kvg.OpenEnvCorpusOrDie()
+	file := corpus.Path(*fileFlag)
+	svg, _, err := corpus.Grab(file)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %s\n", corpus.Rel(file), err)
+		os.Exit(1)
+	}
+	perm, err := kvg.ParsePermutation(*orderFlag, len(svg.GetPaths()))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err)
+		os.Exit(1)
+	}
+	if *dryRun {
+		fmt.Printf("Before:\n")
+		printOrder(svg, nil)
+	}
+	err = svg.ReorderStrokes(perm)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %s\n", corpus.Rel(file), err)
+		os.Exit(1)
+	}
+	if *dryRun {
+		fmt.Printf("After:\n")
+		printOrder(svg, perm)
+		return
+	}
+	err = svg.Save(file)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %s\n", corpus.Rel(file), err)
+		os.Exit(1)
+	}
+}
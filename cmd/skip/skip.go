@@ -137,21 +137,21 @@ func guessShape(base *kvg.Group) (first, second, third int) {
 		}
 	}
 	switch pos {
-	case "left":
+	case kvg.PositionLeft:
 		return SkipLeftRight, nchild0, nremaining
-	case "tare", "nyo", "kamae", "⿵A":
-		if pos == "kamae" && element == "行" {
+	case kvg.PositionTare, kvg.PositionNyo, kvg.PositionKamae, kvg.PositionSurroundAboveA:
+		if pos == kvg.PositionKamae && element == "行" {
 			return SkipLeftRight, nchild0, nremaining
 		}
-		if pos == "tare" && (element == "户" || element == "戸") {
+		if pos == kvg.PositionTare && (element == "户" || element == "戸") {
 			return SkipUpDown, nchild0, nremaining
 		}
 		return SkipEnclosure, nchild0, nremaining
 		// "⿶2" is used in 輿 and 鼎, I don't remember why although I
 		// think I did that (BKB).
-	case "nyoc", "tarec", "⿶", "⿶2":
+	case kvg.PositionNyoc, kvg.PositionTarec, kvg.PositionSurroundBelow, kvg.PositionSurroundBelow2:
 		return SkipEnclosure, nremaining, nchild0
-	case "top":
+	case kvg.PositionTop:
 		return SkipUpDown, nchild0, nremaining
 	}
 	if len(pos) > 0 {
@@ -164,7 +164,7 @@ func guessShape(base *kvg.Group) (first, second, third int) {
 	// This catches about four errors as of 2024-06-20, but hopefully
 	// those will be fixed and this won't catch anything eventually.
 	// See https://github.com/KanjiVG/kanjivg/issues/454.
-	if pos1 == "kamae" {
+	if pos1 == kvg.PositionKamae {
 		if PrintKamae {
 			fmt.Printf("%s: Kamae without matching kamaec\n", kanji)
 		}
@@ -240,10 +240,10 @@ func makeSkip(file string) {
 		if PrintNoPos {
 			if isUnusual {
 				fmt.Printf("%s: %s %d %d, unusual skip is %s\n",
-					kvg.TFile(file), ks, a, b, skip)
+					corpus.Rel(file), ks, a, b, skip)
 			} else {
 				fmt.Printf("%s: no position %s %d %d, skip is %s\n",
-					kvg.TFile(file), ks, a, b, skip)
+					corpus.Rel(file), ks, a, b, skip)
 			}
 		}
 	}
@@ -254,7 +254,7 @@ func makeSkip(file string) {
 			if PrintWrong {
 				mismatch++
 				fmt.Printf("Mismatch %d: %s (%s) genuine SKIP %s != our guess %d-%d-%d\n",
-					mismatch, ks, kvg.TFile(file), skip, bshape, a, b)
+					mismatch, ks, corpus.Rel(file), skip, bshape, a, b)
 			}
 			guesswrong++
 		} else {
@@ -265,7 +265,7 @@ func makeSkip(file string) {
 			switch bshape {
 			case singleChild:
 				if PrintSingles {
-					fmt.Printf("%s: skip = %s\n", kvg.TFile(file), skip)
+					fmt.Printf("%s: skip = %s\n", corpus.Rel(file), skip)
 				}
 			}
 			guessfail++
@@ -282,7 +282,7 @@ func makeSkip(file string) {
 		}
 		if strCount != skipCount {
 			if PrintCounts {
-				fmt.Printf("%c: %s: %s ", kanji, kvg.TFile(file), skip)
+				fmt.Printf("%c: %s: %s ", kanji, corpus.Rel(file), skip)
 				fmt.Printf("Stroke count disagreement: %d != %d\n",
 					strCount, skipCount)
 			}
@@ -294,7 +294,7 @@ func makeSkip(file string) {
 	} else if a != unknown {
 		if PrintAMistake {
 			fmt.Printf("%c: %s: %d != %d (skip)\n",
-				kanji, kvg.TFile(file), a, sc.a)
+				kanji, corpus.Rel(file), a, sc.a)
 		}
 	}
 	if b == sc.b {
@@ -306,6 +306,8 @@ func makeSkip(file string) {
 // Just check one kanji
 var indi string
 
+var corpus *kvg.Corpus
+
 func main() {
 	indiFlag := flag.String("indi", "", "An individual kanji to check against")
 	singlesFlag := flag.Bool("singles", false, "Print when the base group only has a single child")
@@ -334,7 +336,12 @@ func main() {
 		fmt.Fprintf(os.Stderr, "Failed to parse the skip.json file: %s\n", err)
 		os.Exit(1)
 	}
-	kvg.ExamineAllFilesSimple(makeSkip)
+	corpus = kvg.OpenEnvCorpusOrDie()
+	err = corpus.ExamineAllFilesSimple(makeSkip)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error examining files: %s\n", err)
+		os.Exit(1)
+	}
 	fmt.Printf("ok %d guess wrong %d total %d  [should = %d]\n",
 		okskip, guesswrong, total, okskip+guesswrong)
 	fmt.Printf("No group = %d no position = %d, one child = %d [total = %d]\n",
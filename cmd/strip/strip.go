@@ -6,11 +6,14 @@
 package main
 
 import (
+	"encoding/xml"
+	"flag"
 	"kvg"
 	"log"
 	"os"
 	"path/filepath"
 	"regexp"
+	"strings"
 )
 
 // This is the heading for our files.
@@ -21,17 +24,29 @@ var StrippedHeading = kvg.Heading
 
 var attlistRe = regexp.MustCompile(`(?ms)\s*(\[\s*<\!ATTLIST.*?>\s*)+\]`)
 
-// This should be an option in theory but for now we'll use this
-// directory.
+// The directory to write the stripped files to. By default this is
+// the directory "stripped" next to the KanjiVG "kanji" directory.
 
-var stripDir = "/home/ben/software/kanjivg/stripped"
+var stripDir string
+
+// Remove the kvg: attributes which the library doesn't know about
+// from attrs.
+
+func stripExtra(attrs []xml.Attr) (kept []xml.Attr) {
+	for _, a := range attrs {
+		if !strings.HasPrefix(a.Name.Local, "kvg:") {
+			kept = append(kept, a)
+		}
+	}
+	return kept
+}
 
 func strip(file string) {
 	svg := kvg.ReadKanjiFileOrDie(file)
 	base := svg.BaseGroup()
 	paths := base.GetPaths()
 	groups := base.GetGroups()
-	for i, g := range groups {
+	for _, g := range groups {
 		g.Element = ""
 		g.Variant = false
 		g.Partial = false
@@ -43,17 +58,24 @@ func strip(file string) {
 		g.Position = ""
 		g.Radical = ""
 		g.Phon = ""
-		groups[i] = g
+		g.ExtraAttrs = stripExtra(g.ExtraAttrs)
 	}
-	for j, p := range paths {
+	for _, p := range paths {
 		p.Type = ""
-		paths[j] = p
+		p.ExtraAttrs = stripExtra(p.ExtraAttrs)
 	}
 	file = filepath.Base(file)
 	svg.WriteKanjiFile(stripDir + "/" + file)
 }
 
 func main() {
+	outFlag := flag.String("out", "", "Directory to write the stripped files to")
+	flag.Parse()
+	corpus := kvg.OpenEnvCorpusOrDie()
+	stripDir = *outFlag
+	if len(stripDir) == 0 {
+		stripDir = filepath.Join(filepath.Dir(corpus.Dir), "stripped")
+	}
 	StrippedHeading = attlistRe.ReplaceAllString(kvg.Heading, "")
 	_, err := os.Stat(stripDir)
 	if os.IsNotExist(err) {
@@ -63,5 +85,8 @@ func main() {
 		}
 	}
 	kvg.Heading = StrippedHeading
-	kvg.ExamineAllFilesSimple(strip)
+	err = corpus.ExamineAllFilesSimple(strip)
+	if err != nil {
+		log.Fatalf("Error examining files: %s", err)
+	}
 }
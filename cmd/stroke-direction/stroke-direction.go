@@ -0,0 +1,79 @@
+// Find strokes which are drawn the opposite way to what their
+// kvg:type says, and optionally reverse them.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"kvg"
+	"os"
+)
+
+var (
+	corpus *kvg.Corpus
+	// Reverse the strokes which are found.
+	fix bool
+	// Only report strokes with at least this confidence.
+	minConfidence float64
+	// The number of strokes found.
+	found int
+)
+
+func check(file string) {
+	svg, base, err := corpus.Grab(file)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %s\n", corpus.Rel(file), err)
+		return
+	}
+	changed := false
+	for _, p := range base.GetPaths() {
+		e, err := p.CheckDirection(corpus.Rel(file))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %s: %s\n", corpus.Rel(file), p.ID, err)
+			continue
+		}
+		if e == nil || e.Confidence < minConfidence {
+			continue
+		}
+		fmt.Printf("%s\n", e)
+		found++
+		if !fix {
+			continue
+		}
+		err = p.Reverse()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %s: %s\n", corpus.Rel(file), p.ID, err)
+			continue
+		}
+		changed = true
+	}
+	if !changed {
+		return
+	}
+	err = svg.Save(file)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %s\n", corpus.Rel(file), err)
+	}
+}
+
+func main() {
+	fileFlag := flag.String("file", "", "Check only this file")
+	flag.BoolVar(&fix, "fix", false, "Reverse the strokes which are backwards")
+	flag.Float64Var(&minConfidence, "confidence", 0.5,
+		"Only report strokes which are backwards with at least this confidence, from 0 to 1")
+	flag.Parse()
+	corpus = kvg.OpenEnvCorpusOrDie()
+	if len(*fileFlag) != 0 {
+		check(corpus.Path(*fileFlag))
+	} else {
+		err := corpus.ExamineAllFilesSimple(check)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error examining files: %s\n", err)
+			os.Exit(1)
+		}
+	}
+	if found > 0 {
+		fmt.Fprintf(os.Stderr, "%d backwards strokes\n", found)
+	}
+}
@@ -0,0 +1,85 @@
+// List strokes whose kvg:type does not fit their shape, such as types
+// which have been shuffled by mistake.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"kvg"
+	"os"
+	"strings"
+)
+
+var (
+	corpus *kvg.Corpus
+	// How far below the best guess the recorded type may be.
+	margin float64
+	// The number of strokes found.
+	found int
+)
+
+// Get the score of the best alternative of kvgType.
+func typeScore(kvgType string, guesses []kvg.StrokeGuess) (score float64, ok bool) {
+	for _, r := range kvg.StrokeTypes(kvgType) {
+		for _, g := range guesses {
+			if g.Fits(string(r)) && (!ok || g.Score > score) {
+				score, ok = g.Score, true
+			}
+		}
+	}
+	return score, ok
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func check(file string) {
+	_, base, err := corpus.Grab(file)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %s\n", corpus.Rel(file), err)
+		return
+	}
+	for _, p := range base.GetPaths() {
+		guesses, err := p.ClassifyStroke()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %s: %s\n", corpus.Rel(file), p.ID, err)
+			continue
+		}
+		if kvg.TypeFits(p.Type, guesses, margin) {
+			continue
+		}
+		var best []string
+		for _, g := range guesses[:min(3, len(guesses))] {
+			best = append(best, fmt.Sprintf("%s (%.2f)", g.Type, g.Score))
+		}
+		score, _ := typeScore(p.Type, guesses)
+		fmt.Printf("%s: %s: type %s (%.2f) looks like %s\n", corpus.Rel(file),
+			p.ID, p.Type, score, strings.Join(best, ", "))
+		found++
+	}
+}
+
+func main() {
+	fileFlag := flag.String("file", "", "Check only this file")
+	flag.Float64Var(&margin, "margin", 0.2,
+		"How far the score of the recorded type may be below the best guess")
+	flag.Parse()
+	corpus = kvg.OpenEnvCorpusOrDie()
+	if len(*fileFlag) != 0 {
+		check(corpus.Path(*fileFlag))
+	} else {
+		err := corpus.ExamineAllFilesSimple(check)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error examining files: %s\n", err)
+			os.Exit(1)
+		}
+	}
+	if found > 0 {
+		fmt.Fprintf(os.Stderr, "%d strokes do not fit their type\n", found)
+	}
+}
@@ -0,0 +1,282 @@
+package kvg
+
+import (
+	"fmt"
+	"math"
+)
+
+// Where the strokes of a kanji cross and touch.
+
+// The ways two strokes can meet.
+type ContactKind int
+
+const (
+	// The strokes cross, away from the ends of either.
+	Cross ContactKind = iota
+	// An end of one stroke touches the other away from its ends, as
+	// the vertical of 丁 touches the horizontal.
+	EndToBody
+	// An end of one stroke touches an end of the other, as at the
+	// corners of 口.
+	EndToEnd
+	// An end of one stroke is near the other, but does not touch
+	// it. These are often strokes which should touch but have been
+	// left a few units apart.
+	NearMiss
+)
+
+var contactKindNames = []string{"cross", "end to body", "end to end", "near miss"}
+
+func (k ContactKind) String() string {
+	if k < 0 || int(k) >= len(contactKindNames) {
+		return fmt.Sprintf("ContactKind(%d)", int(k))
+	}
+	return contactKindNames[k]
+}
+
+// A place where two strokes meet.
+type Contact struct {
+	Kind ContactKind
+	// The strokes, as indices into the Paths of the StrokeGraph,
+	// with A less than B.
+	A, B int
+	// Where the strokes meet. For a touch or a near miss, this is
+	// the end of the stroke which touches.
+	At Point
+	// How far along strokes A and B the contact is, as a fraction of
+	// their lengths from 0 at the start to 1 at the end.
+	TA, TB float64
+	// The distance between the strokes, which is zero for a
+	// crossing.
+	Distance float64
+}
+
+// The graph of the contacts between the strokes of a kanji.
+type StrokeGraph struct {
+	// The strokes, in order.
+	Paths []*Path
+	// The contacts, ordered by A and then B.
+	Contacts []Contact
+}
+
+// Get the contacts between strokes a and b.
+func (g *StrokeGraph) Between(a, b int) (contacts []Contact) {
+	if a > b {
+		a, b = b, a
+	}
+	for _, c := range g.Contacts {
+		if c.A == a && c.B == b {
+			contacts = append(contacts, c)
+		}
+	}
+	return contacts
+}
+
+// Get the contacts of stroke a with any other stroke.
+func (g *StrokeGraph) Of(a int) (contacts []Contact) {
+	for _, c := range g.Contacts {
+		if c.A == a || c.B == a {
+			contacts = append(contacts, c)
+		}
+	}
+	return contacts
+}
+
+// The tolerance of the polylines the contacts are found from.
+const contactTolerance = 0.05
+
+// A stroke made into a polyline, with the distance along the stroke
+// of each point.
+type polyline struct {
+	points []Point
+	dist   []float64
+	box    Rect
+}
+
+func newPolyline(path SVGPath) (l polyline) {
+	l.points = path.Flatten(contactTolerance)
+	l.dist = make([]float64, len(l.points))
+	l.box = EmptyRect
+	for i, p := range l.points {
+		if i > 0 {
+			l.dist[i] = l.dist[i-1] + p.sub(l.points[i-1]).length()
+		}
+		l.box = l.box.addPoint(p)
+	}
+	return l
+}
+
+func (l polyline) length() float64 {
+	if len(l.dist) == 0 {
+		return 0
+	}
+	return l.dist[len(l.dist)-1]
+}
+
+// The fraction of the length of l at distance s along it.
+func (l polyline) fraction(s float64) float64 {
+	if l.length() == 0 {
+		return 0
+	}
+	return s / l.length()
+}
+
+// Find the point of l nearest to q, its distance from q, and how far
+// along l it is.
+func (l polyline) nearest(q Point) (at Point, d, s float64) {
+	d = math.Inf(1)
+	for i := 1; i < len(l.points); i++ {
+		a, b := l.points[i-1], l.points[i]
+		ab := b.sub(a)
+		var f float64
+		if n := ab.X*ab.X + ab.Y*ab.Y; n > 0 {
+			v := q.sub(a)
+			f = math.Max(0, math.Min(1, (v.X*ab.X+v.Y*ab.Y)/n))
+		}
+		p := a.add(ab.scale(f))
+		if pd := q.sub(p).length(); pd < d {
+			at, d, s = p, pd, l.dist[i-1]+f*ab.length()
+		}
+	}
+	return at, d, s
+}
+
+// Find where the pieces a0 to a1 and b0 to b1 cross, as the fractions
+// along each.
+func crossing(a0, a1, b0, b1 Point) (fa, fb float64, ok bool) {
+	da, db := a1.sub(a0), b1.sub(b0)
+	den := da.X*db.Y - da.Y*db.X
+	if den == 0 {
+		return 0, 0, false
+	}
+	v := b0.sub(a0)
+	fa = (v.X*db.Y - v.Y*db.X) / den
+	fb = (v.X*da.Y - v.Y*da.X) / den
+	if fa < 0 || fa > 1 || fb < 0 || fb > 1 {
+		return 0, 0, false
+	}
+	return fa, fb, true
+}
+
+// Find the contacts between the strokes paths. An end of a stroke
+// within touch of another stroke touches it, and one within near is a
+// near miss. A crossing within touch of the end of either stroke is
+// counted as a touch rather than a crossing. The distances are in the
+// units of the SVG, in which the KanjiVG strokes are 3 wide, so a
+// touch of 1.5 and a near of 6 are reasonable values.
+func StrokeContacts(paths []*Path, touch, near float64) (g *StrokeGraph, err error) {
+	g = &StrokeGraph{Paths: paths}
+	lines := make([]polyline, len(paths))
+	for i, p := range paths {
+		path, err := p.Parse()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", p.ID, err)
+		}
+		lines[i] = newPolyline(path)
+	}
+	for a := range lines {
+		for b := a + 1; b < len(lines); b++ {
+			g.Contacts = append(g.Contacts, contacts(a, b, lines[a], lines[b], touch, near)...)
+		}
+	}
+	return g, nil
+}
+
+// Find the contacts between the strokes of svg. See StrokeContacts.
+func (svg *SVG) StrokeGraph(touch, near float64) (g *StrokeGraph, err error) {
+	base, err := svg.FindBaseGroup()
+	if err != nil {
+		return nil, err
+	}
+	return StrokeContacts(base.GetPaths(), touch, near)
+}
+
+// Find the contacts between strokes a and b, whose polylines are la
+// and lb.
+func contacts(a, b int, la, lb polyline, touch, near float64) (cs []Contact) {
+	if len(la.points) < 2 || len(lb.points) < 2 {
+		return nil
+	}
+	grow := func(r Rect) Rect {
+		return Rect{r.Min.sub(Point{near, near}), r.Max.add(Point{near, near})}
+	}
+	if !overlap(grow(la.box), lb.box) {
+		return nil
+	}
+	// Is s within touch of either end of l?
+	nearEnd := func(l polyline, s float64) bool {
+		return s <= touch || l.length()-s <= touch
+	}
+	for i := 1; i < len(la.points); i++ {
+		for j := 1; j < len(lb.points); j++ {
+			fa, fb, ok := crossing(la.points[i-1], la.points[i], lb.points[j-1], lb.points[j])
+			if !ok {
+				continue
+			}
+			sa := la.dist[i-1] + fa*(la.dist[i]-la.dist[i-1])
+			sb := lb.dist[j-1] + fb*(lb.dist[j]-lb.dist[j-1])
+			if nearEnd(la, sa) || nearEnd(lb, sb) {
+				continue
+			}
+			at := la.points[i-1].add(la.points[i].sub(la.points[i-1]).scale(fa))
+			// A crossing at a point of both polylines is found
+			// twice.
+			if len(cs) > 0 && cs[len(cs)-1].At.sub(at).length() <= touch {
+				continue
+			}
+			cs = append(cs, Contact{Kind: Cross, A: a, B: b, At: at,
+				TA: la.fraction(sa), TB: lb.fraction(sb)})
+		}
+	}
+	// The ends of a on b, and then the ends of b on a.
+	ends := func(from, to polyline, swap bool) {
+		for _, s := range []float64{0, from.length()} {
+			end := from.points[0]
+			if s > 0 {
+				end = from.points[len(from.points)-1]
+			}
+			_, d, st := to.nearest(end)
+			if d > near {
+				continue
+			}
+			c := Contact{Kind: EndToBody, A: a, B: b, At: end,
+				TA: from.fraction(s), TB: to.fraction(st), Distance: d}
+			if nearEnd(to, st) {
+				c.Kind = EndToEnd
+				// Use the end of the other stroke, so that the
+				// contact is the same from either side.
+				c.TB = 0
+				if to.length()-st < st {
+					c.TB = 1
+				}
+			}
+			if swap {
+				c.TA, c.TB = c.TB, c.TA
+			}
+			if d > touch {
+				c.Kind = NearMiss
+			}
+			if c.Kind == EndToEnd || c.Kind == NearMiss {
+				dup := false
+				for _, o := range cs {
+					if o.Kind == c.Kind && o.TA == c.TA && o.TB == c.TB {
+						dup = true
+					}
+				}
+				if dup {
+					continue
+				}
+			}
+			cs = append(cs, c)
+		}
+	}
+	ends(la, lb, false)
+	ends(lb, la, true)
+	return cs
+}
+
+// Do r and o have any points in common?
+func overlap(r, o Rect) bool {
+	return r.Min.X <= o.Max.X && o.Min.X <= r.Max.X &&
+		r.Min.Y <= o.Max.Y && o.Min.Y <= r.Max.Y
+}
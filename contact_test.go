@@ -0,0 +1,56 @@
+package kvg
+
+import "testing"
+
+func TestStrokeContacts(t *testing.T) {
+	tests := []struct {
+		d1, d2   string
+		kind     ContactKind
+		at       Point
+		ta, tb   float64
+		distance float64
+	}{
+		// 十
+		{"M10,50h80", "M50,10v80", Cross, Point{50, 50}, 0.5, 0.5, 0},
+		// 丁
+		{"M10,10h80", "M50,11v80", EndToBody, Point{50, 11}, 0.5, 0, 1},
+		// The corner of 口.
+		{"M10,10v50", "M10,10h50", EndToEnd, Point{10, 10}, 0, 0, 0},
+		{"M10,10h30", "M44,10h30", NearMiss, Point{40, 10}, 1, 0, 4},
+	}
+	for _, test := range tests {
+		paths := []*Path{{D: test.d1}, {D: test.d2}}
+		g, err := StrokeContacts(paths, 1.5, 6)
+		if err != nil {
+			t.Fatalf("Error finding contacts: %s", err)
+		}
+		cs := g.Between(1, 0)
+		if len(cs) != 1 {
+			t.Errorf("%s and %s have contacts %v", test.d1, test.d2, cs)
+			continue
+		}
+		c := cs[0]
+		if c.Kind != test.kind || !near(c.At, test.at) || c.TA != test.ta ||
+			c.TB != test.tb || c.Distance != test.distance {
+			t.Errorf("%s and %s have contact %+v", test.d1, test.d2, c)
+		}
+	}
+	svg, err := ReadKanjiFile(bin() + "/t/08475.svg")
+	if err != nil {
+		t.Fatalf("Error reading: %s", err)
+	}
+	g, err := svg.StrokeGraph(1.5, 6)
+	if err != nil {
+		t.Fatalf("Error finding contacts: %s", err)
+	}
+	// The two verticals of 艹 cross the horizontal.
+	for _, b := range []int{1, 2} {
+		cs := g.Between(0, b)
+		if len(cs) != 1 || cs[0].Kind != Cross {
+			t.Errorf("Contacts of s1 and s%d are %v", b+1, cs)
+		}
+	}
+	if len(g.Of(0)) < 2 {
+		t.Errorf("Contacts of s1 are %v", g.Of(0))
+	}
+}
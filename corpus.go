@@ -0,0 +1,209 @@
+package kvg
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// The environment variable read by OpenEnvCorpus. This should be set
+// to the "kanji" directory of a copy of the KanjiVG repository.
+const CorpusEnv = "KANJIVG_DIR"
+
+// A collection of KanjiVG files, usually the "kanji" directory of the
+// KanjiVG repository. Several corpora, for example the released files
+// and a working copy, may be used at the same time.
+type Corpus struct {
+	// The directory the corpus was opened from, or the empty string
+	// if it was made from an fs.FS using NewCorpus.
+	Dir string
+	// The files of the corpus.
+	FS fs.FS
+}
+
+// Make a corpus from the files in fsys. The file names given to the
+// functions called by the methods of the corpus are the names within
+// fsys, such as "08475.svg".
+func NewCorpus(fsys fs.FS) (c *Corpus) {
+	return &Corpus{FS: fsys}
+}
+
+// Open the corpus in the directory dir. The file names given to the
+// functions called by the methods of the corpus are file system paths
+// starting with dir, so they can be written back to.
+func OpenCorpus(dir string) (c *Corpus, err error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, &Error{Op: "OpenCorpus", Name: dir, Kind: ErrNoCorpus,
+			Err: fmt.Errorf("not a directory")}
+	}
+	return &Corpus{Dir: dir, FS: os.DirFS(dir)}, nil
+}
+
+// Open the corpus in the directory given by the environment variable
+// KANJIVG_DIR. The error is ErrNoCorpus if the variable is not set.
+func OpenEnvCorpus() (c *Corpus, err error) {
+	dir := os.Getenv(CorpusEnv)
+	if len(dir) == 0 {
+		return nil, &Error{Op: "OpenEnvCorpus", Name: CorpusEnv, Kind: ErrNoCorpus,
+			Err: fmt.Errorf("set %s to the KanjiVG kanji directory", CorpusEnv)}
+	}
+	return OpenCorpus(dir)
+}
+
+// Open the corpus given by KANJIVG_DIR, or exit the program if that
+// fails. This is for the command-line tools.
+func OpenEnvCorpusOrDie() (c *Corpus) {
+	c, err := OpenEnvCorpus()
+	die(err, "Error opening the KanjiVG files")
+	return c
+}
+
+// Convert a name within the corpus file system, such as "08475.svg",
+// into the name given to callers, which is a file system path if the
+// corpus has a directory.
+func (c *Corpus) Path(name string) string {
+	if len(c.Dir) == 0 {
+		return name
+	}
+	return filepath.Join(c.Dir, filepath.FromSlash(name))
+}
+
+// Get the name of file relative to the corpus, for example
+// "08475.svg" from "/home/me/kanjivg/kanji/08475.svg". This is useful
+// for printing messages.
+func (c *Corpus) Rel(file string) string {
+	if len(c.Dir) == 0 {
+		return file
+	}
+	rel, err := filepath.Rel(c.Dir, file)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return file
+	}
+	return filepath.ToSlash(rel)
+}
+
+// The name of file within the corpus file system.
+func (c *Corpus) fsName(file string) string {
+	name := c.Rel(file)
+	return path.Clean(filepath.ToSlash(name))
+}
+
+// Read the contents of file, which is either a name returned by one
+// of the corpus methods, or a name relative to the corpus.
+func (c *Corpus) ReadFile(file string) (contents []byte, err error) {
+	return fs.ReadFile(c.FS, c.fsName(file))
+}
+
+// Read the kanji in file, which is either a name returned by one of
+// the corpus methods, or a name relative to the corpus.
+func (c *Corpus) ReadKanjiFile(file string) (kanjivg SVG, err error) {
+	contents, err := c.ReadFile(file)
+	if err != nil {
+		return kanjivg, err
+	}
+	return ParseKanji(contents)
+}
+
+// Read the kanji in file, and return both the SVG and its base group,
+// like Grab.
+func (c *Corpus) Grab(file string) (svgPtr *SVG, base *Group, err error) {
+	svg, err := c.ReadKanjiFile(file)
+	if err != nil {
+		return nil, nil, err
+	}
+	base, err = svg.FindBaseGroup()
+	if err != nil {
+		return nil, nil, err
+	}
+	return &svg, base, nil
+}
+
+// The name of the standard file for kanji, such as
+// "/home/me/kanjivg/kanji/08475.svg". This does not check whether the
+// file exists.
+func (c *Corpus) FileName(kanji rune) string {
+	return c.Path(fmt.Sprintf("%05x.svg", kanji))
+}
+
+// Read the standard file for kanji.
+func (c *Corpus) Lookup(kanji rune) (kanjivg SVG, err error) {
+	return c.ReadKanjiFile(c.FileName(kanji))
+}
+
+// Get the names of the variant files of kanji, such as
+// "08475-Kaisho.svg", in alphabetical order. This does not include
+// the standard file.
+func (c *Corpus) Variants(kanji rune) (files []string, err error) {
+	names, err := fs.Glob(c.FS, fmt.Sprintf("%05x-*.svg", kanji))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		files = append(files, c.Path(name))
+	}
+	return files, nil
+}
+
+// Get the names of all the kanji files in the corpus, in
+// alphabetical order, skipping backup files.
+func (c *Corpus) Files() (files []string, err error) {
+	err = fs.WalkDir(c.FS, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		file := c.Path(name)
+		if Backup.MatchString(file) {
+			return nil
+		}
+		files = append(files, file)
+		return nil
+	})
+	return files, err
+}
+
+// Go through each file in the corpus, read its contents, and call fn
+// on each of them. If a file cannot be read, this stops and returns
+// the error. See also ExamineAllFilesSimple, which doesn't read the
+// file contents first.
+func (c *Corpus) ExamineAllFiles(fn SVGFileFunc) (err error) {
+	files, err := c.Files()
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		kanjivg, err := c.ReadKanjiFile(file)
+		if err != nil {
+			return err
+		}
+		fn(file, kanjivg)
+	}
+	return nil
+}
+
+// Go through each file in the corpus and call fn on them. This
+// doesn't read the contents, unlike ExamineAllFiles. This is usually
+// better if you want to just check some files, since you can check the
+// Unicode ID of the character using FileToNum, and decide whether to
+// read it all in, rather than reading everything for all files.
+func (c *Corpus) ExamineAllFilesSimple(fn func(file string)) (err error) {
+	files, err := c.Files()
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		fn(file)
+	}
+	return nil
+}
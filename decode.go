@@ -0,0 +1,474 @@
+package kvg
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Decoding the XML of the KanjiVG files with attention to namespaces.
+//
+// The KanjiVG attributes, such as kvg:element, are recognised by the
+// namespace URI http://kanjivg.tagaini.net rather than by the prefix,
+// so a file may bind the namespace to any prefix. An attribute such
+// as element or type without a prefix is not a KanjiVG attribute, and
+// is kept as an extra attribute.
+//
+// The KanjiVG files don't declare the kvg prefix with an xmlns:kvg
+// attribute, but with a #FIXED attribute in the ATTLIST of the
+// DOCTYPE. The XML decoder doesn't read the DOCTYPE, so it leaves the
+// prefix unresolved, and the declarations in the DOCTYPE are read
+// here instead.
+
+// The namespace of the KanjiVG attributes.
+const KVGNamespace = "http://kanjivg.tagaini.net"
+
+// The namespace of the SVG elements.
+const SVGNamespace = "http://www.w3.org/2000/svg"
+
+// The namespace of the prefix "xml", which the XML decoder resolves
+// itself.
+const xmlNamespace = "http://www.w3.org/XML/1998/namespace"
+
+// How the decoder treats prefixes which are not declared.
+type NamespaceMode int
+
+const (
+	// Attributes with the prefix "kvg" are KanjiVG attributes even if
+	// the prefix is not declared, and other undeclared prefixes are
+	// kept as they are.
+	Lenient NamespaceMode = iota
+	// Any attribute or element whose prefix is not declared, either
+	// by an xmlns attribute or in the DOCTYPE, is an error.
+	Strict
+)
+
+// This matches a namespace declaration in the ATTLIST of a DOCTYPE,
+// like the one in Heading.
+var fixedXMLNS = regexp.MustCompile(`xmlns:([\w.-]+)\s+CDATA\s+#FIXED\s+["']([^"']*)["']`)
+
+// The state of the decoder while reading a file.
+type decodeState struct {
+	mode NamespaceMode
+	// The declared prefixes of each element being read, from the
+	// outermost to the innermost. The first one holds the ones from
+	// the DOCTYPE.
+	scopes []map[string]string
+	// The undeclared prefixes found in strict mode.
+	problems []string
+}
+
+func newDecodeState(mode NamespaceMode) (st *decodeState) {
+	st = &decodeState{mode: mode}
+	st.scopes = []map[string]string{{"xml": xmlNamespace}}
+	return st
+}
+
+// Read the namespace declarations from a DOCTYPE.
+func (st *decodeState) readDoctype(dir xml.Directive) {
+	for _, m := range fixedXMLNS.FindAllSubmatch(dir, -1) {
+		st.scopes[0][string(m[1])] = string(m[2])
+	}
+}
+
+// Add the namespace declarations of start.
+func (st *decodeState) push(start xml.StartElement) {
+	scope := map[string]string{}
+	for _, attr := range start.Attr {
+		switch {
+		case attr.Name.Space == "xmlns":
+			scope[attr.Name.Local] = attr.Value
+		case attr.Name.Space == "" && attr.Name.Local == "xmlns":
+			scope[""] = attr.Value
+		}
+	}
+	st.scopes = append(st.scopes, scope)
+}
+
+// Remove the namespace declarations of the element which has ended.
+func (st *decodeState) pop() {
+	st.scopes = st.scopes[:len(st.scopes)-1]
+}
+
+// Get the URI of the namespace of name, which is empty for an
+// attribute without a prefix. An xmlns:x declaration has the URI
+// "xmlns". If the prefix is not declared, ok is false. The XML decoder
+// replaces declared prefixes with their URIs, and leaves undeclared
+// ones as they are. Prefixes cannot contain a colon, but URIs always
+// do.
+func (st *decodeState) resolve(name xml.Name) (uri string, ok bool) {
+	space := name.Space
+	if len(space) == 0 || space == "xmlns" || strings.Contains(space, ":") {
+		return space, true
+	}
+	for i := len(st.scopes) - 1; i >= 0; i-- {
+		if uri, ok := st.scopes[i][space]; ok {
+			return uri, true
+		}
+	}
+	if st.mode == Lenient && space == "kvg" {
+		return KVGNamespace, true
+	}
+	return space, false
+}
+
+// Get the prefix of the namespace uri, preferring the innermost
+// declaration.
+func (st *decodeState) prefix(uri string) (prefix string, ok bool) {
+	for i := len(st.scopes) - 1; i >= 0; i-- {
+		for p, u := range st.scopes[i] {
+			if u == uri {
+				return p, true
+			}
+		}
+	}
+	return "", false
+}
+
+// Record an undeclared prefix in strict mode.
+func (st *decodeState) undeclared(d *xml.Decoder, name xml.Name, what string) {
+	if st.mode != Strict {
+		return
+	}
+	st.problems = append(st.problems, fmt.Sprintf("%s %s:%s before byte %d",
+		what, name.Space, name.Local, d.InputOffset()))
+}
+
+// Turn name into a name with the prefix in Local and an empty Space,
+// so that it is written out with the prefix. The KanjiVG namespace
+// always gets the prefix "kvg", since that is the one declared in
+// Heading.
+func (st *decodeState) qualify(name xml.Name) xml.Name {
+	if len(name.Space) == 0 {
+		return name
+	}
+	prefix := name.Space
+	uri, ok := st.resolve(name)
+	switch {
+	case !ok, uri == "xmlns":
+	case uri == KVGNamespace:
+		prefix = "kvg"
+	default:
+		prefix, ok = st.prefix(uri)
+		if !ok {
+			prefix = name.Space
+		}
+	}
+	if len(prefix) == 0 {
+		return xml.Name{Local: name.Local}
+	}
+	return xml.Name{Local: prefix + ":" + name.Local}
+}
+
+// Check the namespace of an attribute, and get its URI and a copy to
+// store as an extra attribute.
+func (st *decodeState) attr(d *xml.Decoder, attr xml.Attr) (uri string, extra xml.Attr) {
+	uri, ok := st.resolve(attr.Name)
+	if !ok {
+		st.undeclared(d, attr.Name, "attribute")
+	}
+	return uri, xml.Attr{Name: st.qualify(attr.Name), Value: attr.Value}
+}
+
+// Is name a plain SVG element, rather than one from some other
+// namespace? Files without an xmlns attribute have no namespace.
+func (st *decodeState) isSVG(d *xml.Decoder, name xml.Name) bool {
+	uri, ok := st.resolve(name)
+	if !ok {
+		st.undeclared(d, name, "element")
+		return false
+	}
+	return uri == "" || uri == SVGNamespace
+}
+
+// Read the contents of an element after its start, calling fn on each
+// child element, until the end of the element. Character data is given
+// to text, if it is not nil.
+func (st *decodeState) children(d *xml.Decoder, start xml.StartElement, text func(xml.CharData), fn func(el xml.StartElement) error) (err error) {
+	for {
+		token, err := d.Token()
+		if err != nil {
+			return err
+		}
+		switch el := token.(type) {
+		case xml.StartElement:
+			err = fn(el)
+			if err != nil {
+				return err
+			}
+		case xml.CharData:
+			if text != nil {
+				text(el)
+			}
+		case xml.EndElement:
+			if el.Name == start.Name {
+				return nil
+			}
+		}
+	}
+}
+
+// Read an element which the library doesn't know about.
+func (n *Node) decode(d *xml.Decoder, start xml.StartElement, st *decodeState) (err error) {
+	st.push(start)
+	defer st.pop()
+	n.XMLName = st.qualify(start.Name)
+	n.Attrs = nil
+	for _, attr := range start.Attr {
+		_, extra := st.attr(d, attr)
+		n.Attrs = append(n.Attrs, extra)
+	}
+	var inner struct {
+		Inner []byte `xml:",innerxml"`
+	}
+	err = d.DecodeElement(&inner, &start)
+	if err != nil {
+		return err
+	}
+	n.Inner = inner.Inner
+	st.checkInner(d, n.Inner)
+	return nil
+}
+
+// Check the prefixes of the elements and attributes in inner, the
+// contents of an unknown element, which are kept as text. This is only
+// done in strict mode. The contents were read by d, so they are well
+// formed.
+func (st *decodeState) checkInner(d *xml.Decoder, inner []byte) {
+	if st.mode != Strict {
+		return
+	}
+	id := xml.NewDecoder(bytes.NewReader(inner))
+	depth := 0
+	defer func() {
+		for ; depth > 0; depth-- {
+			st.pop()
+		}
+	}()
+	for {
+		token, err := id.Token()
+		if err != nil {
+			return
+		}
+		switch el := token.(type) {
+		case xml.StartElement:
+			st.push(el)
+			depth++
+			if _, ok := st.resolve(el.Name); !ok {
+				st.undeclared(d, el.Name, "element")
+			}
+			for _, attr := range el.Attr {
+				st.attr(d, attr)
+			}
+		case xml.EndElement:
+			st.pop()
+			depth--
+		}
+	}
+}
+
+// Read a path.
+func (p *Path) decode(d *xml.Decoder, start xml.StartElement, st *decodeState) (err error) {
+	st.push(start)
+	defer st.pop()
+	for _, attr := range start.Attr {
+		uri, extra := st.attr(d, attr)
+		p.attrOrder = append(p.attrOrder, extra.Name.Local)
+		switch {
+		case uri == "" && attr.Name.Local == "d":
+			p.D = attr.Value
+		case uri == "" && attr.Name.Local == "id":
+			p.ID = attr.Value
+		case uri == "" && attr.Name.Local == "class":
+			p.Class = attr.Value
+		case uri == KVGNamespace && attr.Name.Local == "type":
+			p.Type = attr.Value
+		default:
+			p.ExtraAttrs = append(p.ExtraAttrs, extra)
+		}
+	}
+	// There is no content in the paths.
+	return st.children(d, start, nil, func(el xml.StartElement) error {
+		return d.Skip()
+	})
+}
+
+// Read a text.
+func (t *Text) decode(d *xml.Decoder, start xml.StartElement, st *decodeState) (err error) {
+	st.push(start)
+	defer st.pop()
+	for _, attr := range start.Attr {
+		uri, extra := st.attr(d, attr)
+		t.attrOrder = append(t.attrOrder, extra.Name.Local)
+		switch {
+		case uri == "" && attr.Name.Local == "transform":
+			// A transform which cannot be read is kept rather than
+			// being an error, see Transform.UnmarshalXMLAttr.
+			err = t.Transform.UnmarshalXMLAttr(attr)
+			if err != nil {
+				return err
+			}
+		case uri == "" && attr.Name.Local == "class":
+			t.Class = attr.Value
+		default:
+			t.ExtraAttrs = append(t.ExtraAttrs, extra)
+		}
+	}
+	return st.children(d, start, func(text xml.CharData) {
+		t.Content = append(t.Content, text...)
+	}, func(el xml.StartElement) error {
+		return d.Skip()
+	})
+}
+
+// Read a group and its children.
+func (g *Group) decode(d *xml.Decoder, start xml.StartElement, st *decodeState) (err error) {
+	st.push(start)
+	defer st.pop()
+	for _, attr := range start.Attr {
+		uri, extra := st.attr(d, attr)
+		g.attrOrder = append(g.attrOrder, extra.Name.Local)
+		if uri == "" {
+			switch attr.Name.Local {
+			case "id":
+				g.ID = attr.Value
+				continue
+			case "style":
+				g.Style = attr.Value
+				continue
+			}
+		}
+		if uri == KVGNamespace && g.kvgAttr(attr) {
+			continue
+		}
+		g.ExtraAttrs = append(g.ExtraAttrs, extra)
+	}
+	return st.children(d, start, nil, func(el xml.StartElement) (err error) {
+		var c Child
+		svg := st.isSVG(d, el.Name)
+		switch {
+		case svg && el.Name.Local == "g":
+			c.IsGroup = true
+			err = c.Group.decode(d, el, st)
+		case svg && el.Name.Local == "path":
+			err = c.Path.decode(d, el, st)
+		case svg && el.Name.Local == "text":
+			c.IsText = true
+			err = c.Text.decode(d, el, st)
+		default:
+			n := Node{After: len(g.Children)}
+			err = n.decode(d, el, st)
+			if err == nil {
+				g.ExtraNodes = append(g.ExtraNodes, n)
+			}
+			return err
+		}
+		if err != nil {
+			return err
+		}
+		g.Children = append(g.Children, c)
+		return nil
+	})
+}
+
+// Set the field of g given by the KanjiVG attribute attr. The return
+// value is false if attr is not one of the fields of Group.
+func (g *Group) kvgAttr(attr xml.Attr) (ok bool) {
+	switch attr.Name.Local {
+	case "element":
+		g.Element = attr.Value
+	case "number":
+		g.Number = attr.Value
+	case "original":
+		g.Original = attr.Value
+	case "part":
+		g.Part = Part(attr.Value)
+	case "partial":
+		g.Partial = attr.Value == "true"
+	case "phon":
+		g.Phon = attr.Value
+	case "position":
+		g.Position = Position(attr.Value)
+	case "radical":
+		g.Radical = RadicalType(attr.Value)
+	case "radicalForm":
+		g.RadicalForm = attr.Value
+	case "tradForm":
+		g.TradForm = attr.Value
+	case "variant":
+		g.Variant = attr.Value == "true"
+	default:
+		return false
+	}
+	return true
+}
+
+// Read the svg element and everything in it.
+func (svg *SVG) decode(d *xml.Decoder, start xml.StartElement, st *decodeState) (err error) {
+	st.push(start)
+	defer st.pop()
+	svg.XMLName = start.Name
+	for _, attr := range start.Attr {
+		uri, extra := st.attr(d, attr)
+		svg.attrOrder = append(svg.attrOrder, extra.Name.Local)
+		switch {
+		case uri == "" && attr.Name.Local == "xmlns":
+			svg.XMLNS = attr.Value
+		case uri == "" && attr.Name.Local == "width":
+			svg.Width = attr.Value
+		case uri == "" && attr.Name.Local == "height":
+			svg.Height = attr.Value
+		case uri == "" && attr.Name.Local == "viewBox":
+			svg.ViewBox = attr.Value
+		default:
+			svg.ExtraAttrs = append(svg.ExtraAttrs, extra)
+		}
+	}
+	return st.children(d, start, nil, func(el xml.StartElement) (err error) {
+		if st.isSVG(d, el.Name) && el.Name.Local == "g" {
+			var g Group
+			err = g.decode(d, el, st)
+			svg.Groups = append(svg.Groups, g)
+			return err
+		}
+		n := Node{After: len(svg.Groups)}
+		err = n.decode(d, el, st)
+		svg.ExtraNodes = append(svg.ExtraNodes, n)
+		return err
+	})
+}
+
+// Parse a kanji from contents, treating undeclared namespace prefixes
+// according to mode. In Strict mode, the error for undeclared prefixes
+// has Kind ErrNamespace, and lists all of them.
+func ParseKanjiMode(contents []byte, mode NamespaceMode) (kanjivg SVG, err error) {
+	d := xml.NewDecoder(bytes.NewReader(contents))
+	st := newDecodeState(mode)
+	for {
+		token, err := d.Token()
+		if err != nil {
+			return kanjivg, err
+		}
+		if dir, ok := token.(xml.Directive); ok {
+			st.readDoctype(dir)
+			continue
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		err = kanjivg.decode(d, start, st)
+		if err != nil {
+			return kanjivg, err
+		}
+		break
+	}
+	if len(st.problems) > 0 {
+		return kanjivg, &Error{Op: "ParseKanji", Kind: ErrNamespace,
+			Err: fmt.Errorf("%s", strings.Join(st.problems, ", "))}
+	}
+	kanjivg.Link()
+	return kanjivg, nil
+}
@@ -0,0 +1,79 @@
+package kvg
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+const nsTest = `<svg xmlns="http://www.w3.org/2000/svg" xmlns:k="http://kanjivg.tagaini.net" width="109" height="109" viewBox="0 0 109 109">
+<g id="kvg:StrokePaths_04e00">
+<g id="kvg:04e00" k:element="一" element="二">
+	<path id="kvg:04e00-s1" k:type="㇐" type="plain" d="M11,54.25c3.19,0.62,6.25,0.75,9.73,0.5"/>
+</g>
+</g>
+</svg>
+`
+
+func TestNamespace(t *testing.T) {
+	svg, err := ParseKanjiMode([]byte(nsTest), Strict)
+	if err != nil {
+		t.Fatalf("Error parsing: %s", err)
+	}
+	base := svg.BaseGroup()
+	if base.Element != "一" {
+		t.Errorf("Element not read from other prefix: %q", base.Element)
+	}
+	if len(base.ExtraAttrs) != 1 || base.ExtraAttrs[0].Name.Local != "element" {
+		t.Errorf("Attribute without namespace not kept: %v", base.ExtraAttrs)
+	}
+	p := svg.GetPaths()[0]
+	if p.Type != "㇐" || len(p.ExtraAttrs) != 1 || p.ExtraAttrs[0].Value != "plain" {
+		t.Errorf("Wrong type %q or extras %v", p.Type, p.ExtraAttrs)
+	}
+	output, err := svg.Marshal()
+	if err != nil {
+		t.Fatalf("Error marshalling: %s", err)
+	}
+	if !strings.Contains(string(output), `<g id="kvg:04e00" kvg:element="一" element="二">`) {
+		t.Errorf("Bad output:\n%s", output)
+	}
+
+	// The KanjiVG files declare the prefix in the DOCTYPE.
+	contents := read(bin() + "/t/08475.svg")
+	_, err = ParseKanjiMode([]byte(contents), Strict)
+	if err != nil {
+		t.Errorf("Error parsing with DOCTYPE: %s", err)
+	}
+	noDoctype := contents[strings.Index(contents, "<svg"):]
+	_, err = ParseKanjiMode([]byte(noDoctype), Strict)
+	if !errors.Is(err, ErrNamespace) {
+		t.Errorf("Expected ErrNamespace, got %v", err)
+	}
+	svg, err = ParseKanjiMode([]byte(noDoctype), Lenient)
+	if err != nil {
+		t.Fatalf("Error parsing leniently: %s", err)
+	}
+	if svg.BaseGroup().Element != "葵" {
+		t.Errorf("Lenient mode did not read kvg:element")
+	}
+
+	bad := strings.Replace(nsTest, `type="plain"`, `q:type="plain"`, 1)
+	_, err = ParseKanjiMode([]byte(bad), Strict)
+	if !errors.Is(err, ErrNamespace) || !strings.Contains(err.Error(), "q:type") {
+		t.Errorf("Expected ErrNamespace for q:type, got %v", err)
+	}
+	_, err = ParseKanjiMode([]byte(bad), Lenient)
+	if err != nil {
+		t.Errorf("Error parsing leniently: %s", err)
+	}
+
+	// Prefixes inside unknown elements are checked too.
+	inner := strings.Replace(nsTest, "<g id=\"kvg:04e00\"",
+		"<desc><k:note q:lang=\"en\"><r:b>one</r:b></k:note></desc>\n<g id=\"kvg:04e00\"", 1)
+	_, err = ParseKanjiMode([]byte(inner), Strict)
+	if !errors.Is(err, ErrNamespace) || !strings.Contains(err.Error(), "q:lang") ||
+		!strings.Contains(err.Error(), "r:b") || strings.Contains(err.Error(), "k:note") {
+		t.Errorf("Expected ErrNamespace for q:lang and r:b only, got %v", err)
+	}
+}
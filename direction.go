@@ -0,0 +1,201 @@
+package kvg
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+)
+
+// Checking that strokes are drawn in the direction their kvg:type
+// says.
+
+// A direction on the screen, in degrees clockwise from the right, so
+// 0 is to the right, 90 is down and -90 is up.
+type Direction float64
+
+// The direction of v.
+func direction(v Point) Direction {
+	return Direction(math.Atan2(v.Y, v.X) * 180 / math.Pi)
+}
+
+// The names of the directions 0, 45, 90 and so on.
+var directionNames = []string{
+	"right", "down-right", "down", "down-left",
+	"left", "up-left", "up", "up-right",
+}
+
+// Write d as the nearest of the eight compass directions and the
+// angle, such as "down-left (124°)".
+func (d Direction) String() string {
+	i := int(math.Floor(float64(d)/45+0.5)) % 8
+	if i < 0 {
+		i += 8
+	}
+	return fmt.Sprintf("%s (%.0f°)", directionNames[i], float64(d))
+}
+
+// The cosine of the angle between d and e.
+func (d Direction) agree(e Direction) float64 {
+	return math.Cos(float64(d-e) * math.Pi / 180)
+}
+
+// The directions in which a stroke is drawn.
+type StrokeDirection struct {
+	// The direction over the first tenth of the stroke.
+	Start Direction
+	// The direction from the start of the stroke to its end.
+	Chord Direction
+}
+
+func (d StrokeDirection) String() string {
+	return fmt.Sprintf("starting %s, overall %s", d.Start, d.Chord)
+}
+
+// How well d agrees with want, from 1 if they are the same to -1 if
+// they are opposite.
+func (d StrokeDirection) agreement(want StrokeDirection) float64 {
+	return (d.Start.agree(want.Start) + d.Chord.agree(want.Chord)) / 2
+}
+
+// Get the directions in which p is drawn, or false if p has no length.
+// The start direction is measured over the first tenth of the length
+// of p, so that a small kink at the start does not change it.
+func (p SVGPath) Direction() (d StrokeDirection, ok bool) {
+	points := p.Resample(11, 0.01)
+	if len(points) < 2 || points[0] == points[1] {
+		return d, false
+	}
+	d.Start = direction(points[1].sub(points[0]))
+	d.Chord = d.Start
+	if chord := points[len(points)-1].sub(points[0]); chord != (Point{}) {
+		d.Chord = direction(chord)
+	}
+	return d, true
+}
+
+// Get the directions in which a stroke of kvg:type kvgType should be
+// drawn, or false if the type is not known. Where the type has
+// alternatives, such as "㇔/㇀", the first known one is used.
+func ExpectedDirection(kvgType string) (d StrokeDirection, ok bool) {
+	types := StrokeTypes(kvgType)
+	if len(types) == 0 {
+		return d, false
+	}
+	t := strokeTypes[types[0]]
+	return StrokeDirection{t.start, t.chord}, true
+}
+
+// A stroke which seems to be drawn backwards.
+type DirectionError struct {
+	// The file the SVG was read from.
+	File string
+	// The ID of the path.
+	ID string
+	// The kvg:type of the path.
+	Type     string
+	Expected StrokeDirection
+	Actual   StrokeDirection
+	// How sure the check is that the stroke is backwards, from 0 to
+	// 1. A stroke whose start and end directions are both the
+	// opposite of the expected ones has confidence 1.
+	Confidence float64
+}
+
+func (e *DirectionError) Error() string {
+	return fmt.Sprintf("%s: %s: %s stroke drawn backwards: expected %s, got %s (confidence %.2f)",
+		e.File, e.ID, e.Type, e.Expected, e.Actual, e.Confidence)
+}
+
+// Unwrap returns ErrBackwards.
+func (e *DirectionError) Unwrap() error {
+	return ErrBackwards
+}
+
+// Check whether p is drawn in the direction its kvg:type says, and
+// return a DirectionError if it is backwards. Where the type has
+// alternatives, the one nearest to the actual direction is used. A
+// path of an unknown type is not checked. The file name is only used
+// in the error.
+func (p *Path) CheckDirection(file string) (e *DirectionError, err error) {
+	types := StrokeTypes(p.Type)
+	if len(types) == 0 {
+		return nil, nil
+	}
+	path, err := p.Parse()
+	if err != nil {
+		return nil, err
+	}
+	actual, ok := path.Direction()
+	if !ok {
+		return nil, nil
+	}
+	best := math.Inf(-1)
+	var want StrokeDirection
+	for _, r := range types {
+		t := strokeTypes[r]
+		d := StrokeDirection{t.start, t.chord}
+		if a := actual.agreement(d); a > best {
+			best, want = a, d
+		}
+	}
+	if best >= 0 {
+		return nil, nil
+	}
+	return &DirectionError{
+		File:       file,
+		ID:         p.ID,
+		Type:       p.Type,
+		Expected:   want,
+		Actual:     actual,
+		Confidence: -best,
+	}, nil
+}
+
+// Check the directions of all the strokes of svg. See
+// Path.CheckDirection.
+func (svg *SVG) CheckDirections(file string) (errs []*DirectionError, err error) {
+	base, err := svg.FindBaseGroup()
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range base.GetPaths() {
+		e, err := p.CheckDirection(file)
+		if err != nil {
+			return errs, fmt.Errorf("%s: %s: %w", file, p.ID, err)
+		}
+		if e != nil {
+			errs = append(errs, e)
+		}
+	}
+	return errs, nil
+}
+
+// Make p draw the same shape from its end to its start. See
+// SVGPath.Reverse. The new 'd' attribute is written with as many
+// decimal places as the most precise number of the old one.
+func (p *Path) Reverse() (err error) {
+	path, err := p.Parse()
+	if err != nil {
+		return err
+	}
+	f := PathFormat{Precision: decimals(p.D), KeepElision: true}
+	p.D = f.Encode(path.Reverse())
+	return nil
+}
+
+// Get the largest number of decimal places of the numbers in the path
+// d, or -1 if any of them has an exponent.
+func decimals(d string) (n int) {
+	if strings.ContainsAny(d, "eE") {
+		return -1
+	}
+	for _, m := range decimalsRe.FindAllString(d, -1) {
+		if len(m)-1 > n {
+			n = len(m) - 1
+		}
+	}
+	return n
+}
+
+var decimalsRe = regexp.MustCompile(`\.[0-9]+`)
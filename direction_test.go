@@ -0,0 +1,71 @@
+package kvg
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestDirection(t *testing.T) {
+	svg, err := ReadKanjiFile(bin() + "/t/08475.svg")
+	if err != nil {
+		t.Fatalf("Error reading: %s", err)
+	}
+	errs, err := svg.CheckDirections("08475.svg")
+	if err != nil || len(errs) != 0 {
+		t.Fatalf("Errors checking the directions: %v %v", errs, err)
+	}
+	p := svg.GetPaths()[0]
+	before, _ := p.Parse()
+	p.Reverse()
+	e, err := p.CheckDirection("08475.svg")
+	if err != nil || e == nil {
+		t.Fatalf("Reversed %s not found: %v", p.D, err)
+	}
+	if !errors.Is(e, ErrBackwards) || e.Confidence < 0.9 {
+		t.Errorf("Bad error %s", e)
+	}
+	p.Reverse()
+	after, _ := p.Parse()
+	for i := 0; i <= 10; i++ {
+		a, _ := before.PointAt(float64(i) / 10)
+		b, _ := after.PointAt(float64(i) / 10)
+		if a.sub(b).length() > 0.02 {
+			t.Errorf("Reversing twice moved %v to %v", a, b)
+		}
+	}
+	for _, r := range []struct{ d, want string }{
+		{"M0,0h10l5,5zM1,1q1,0,1,1", "M2,2q0-1-1-1M0,0l15,5l-5-5h-10z"},
+		{"M10.123,10.456L20.789,20h5v5", "M25.789,25v-5h-5L10.123,10.456"},
+		{"M0,0A5,5,0,0,1,10,0s5,5,10,0", "M20,0c-5,5-10,0-10,0A5,5,0,0,0,0,0"},
+	} {
+		p := Path{D: r.d}
+		p.Reverse()
+		if p.D != r.want {
+			t.Errorf("Reversed %s to %s, expected %s", r.d, p.D, r.want)
+		}
+	}
+}
+
+// Reversing a path twice gives back the 'd' attribute it had.
+func TestReverseTwice(t *testing.T) {
+	svg, err := ReadKanjiFile(bin() + "/t/08475.svg")
+	if err != nil {
+		t.Fatalf("Error reading: %s", err)
+	}
+	ds := []string{
+		"M10.123,10.456L20.789,20h5v5",
+		"M0,0a5,5,0,0,1,10,0A5,5,0,1,0,30,0l1,1,2,2",
+		"M0,0L10,0L10,10L0,0Z",
+	}
+	for _, p := range svg.GetPaths() {
+		ds = append(ds, p.D)
+	}
+	for _, d := range ds {
+		p := Path{D: d}
+		p.Reverse()
+		p.Reverse()
+		if p.D != d {
+			t.Errorf("Reversing %s twice gave %s", d, p.D)
+		}
+	}
+}
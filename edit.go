@@ -0,0 +1,378 @@
+package kvg
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Structural editing of the tree of groups and paths.
+//
+// Each of the editing methods of SVG changes the tree and then
+// re-links the parent pointers, renumbers the IDs with RenumberXML,
+// and makes the stroke number labels in svg.Groups[1] match the
+// paths. Labels stay with their strokes, so if a stroke is moved, its
+// label moves with it, if a stroke is removed, its label is removed,
+// and if a stroke is added, a new label is made next to its start.
+
+// Check that g is the base group of svg or one of its descendants,
+// and return an error if not.
+func (svg *SVG) checkGroup(op string, g *Group) (err error) {
+	base, err := svg.FindBaseGroup()
+	if err != nil {
+		return err
+	}
+	if g == nil {
+		return &Error{Op: op, Kind: ErrNotInTree}
+	}
+	if sameGroup(g, base) {
+		return nil
+	}
+	for _, a := range g.Ancestors() {
+		if sameGroup(a, base) {
+			return nil
+		}
+	}
+	return &Error{Op: op, Name: g.ID, Kind: ErrNotInTree}
+}
+
+// Number the paths of svg from one, and return a copy of the labels,
+// so that the labels can be matched to the paths after an edit.
+func (svg *SVG) tagPaths() (labels []Child) {
+	for i, p := range getPaths(svg.BaseGroup()) {
+		p.seq = i + 1
+	}
+	if len(svg.Groups) > 1 {
+		labels = append(labels, svg.Groups[1].Children...)
+	}
+	return labels
+}
+
+// Remove the numbers given by tagPaths from the paths in c, so that
+// it can be put into a tree as a new child.
+func untag(c *Child) {
+	if c.IsGroup {
+		for _, p := range getPaths(&c.Group) {
+			p.seq = 0
+		}
+		return
+	}
+	c.Path.seq = 0
+}
+
+// Finish an edit: rebuild the labels from the ones saved by tagPaths
+// in the new order of the paths, then link and renumber svg.
+func (svg *SVG) edited(labels []Child) (err error) {
+	svg.Link()
+	paths := getPaths(svg.BaseGroup())
+	if len(svg.Groups) > 1 {
+		newLabels := make([]Child, 0, len(paths))
+		for _, p := range paths {
+			if p.seq > 0 && p.seq <= len(labels) && labels[p.seq-1].IsText {
+				newLabels = append(newLabels, labels[p.seq-1])
+				continue
+			}
+			newLabels = append(newLabels, newLabel(p))
+		}
+		svg.Groups[1].Children = newLabels
+	}
+	for _, p := range paths {
+		p.seq = 0
+	}
+	return svg.renumberXML()
+}
+
+// Make a label for p, placed to the left of the start of the stroke.
+func newLabel(p *Path) (c Child) {
+	c.IsText = true
+	path, err := PathParser(p.D)
+	if err != nil || len(path.Subpaths) == 0 ||
+		len(path.Subpaths[0].Commands) == 0 {
+		return c
+	}
+	start := path.Subpaths[0].Commands[0].Params
+	if len(start) < 2 {
+		return c
+	}
+	c.Text.Transform = labelTransform(start[0]-6, start[1]+3)
+	return c
+}
+
+// The transform attribute of a label at x, y.
+func labelTransform(x, y float64) Transform {
+	return Translate(x, y).Transform()
+}
+
+// Format v to two decimal places without trailing zeros.
+func shortFloat(v float64) string {
+	s := strconv.FormatFloat(v, 'f', 2, 64)
+	s = strings.TrimRight(s, "0")
+	s = strings.TrimSuffix(s, ".")
+	if s == "-0" {
+		s = "0"
+	}
+	return s
+}
+
+// Insert c into the children of g at position i, so that it becomes
+// g.Children[i]. The group g must be the base group of svg or one of
+// its descendants, and likewise for the other editing methods.
+func (svg *SVG) InsertChild(g *Group, i int, c Child) (err error) {
+	err = svg.checkGroup("InsertChild", g)
+	if err != nil {
+		return err
+	}
+	if i < 0 || i > len(g.Children) {
+		return &Error{Op: "InsertChild", Name: g.ID, Kind: ErrBadIndex,
+			Err: fmt.Errorf("%d not in 0-%d", i, len(g.Children))}
+	}
+	labels := svg.tagPaths()
+	untag(&c)
+	g.Children = append(g.Children, Child{})
+	copy(g.Children[i+1:], g.Children[i:])
+	g.Children[i] = c
+	g.shiftNodes(i, 1)
+	return svg.edited(labels)
+}
+
+// Remove g.Children[i] from g and return it.
+func (svg *SVG) RemoveChild(g *Group, i int) (c Child, err error) {
+	err = svg.checkGroup("RemoveChild", g)
+	if err != nil {
+		return c, err
+	}
+	if i < 0 || i >= len(g.Children) {
+		return c, &Error{Op: "RemoveChild", Name: g.ID, Kind: ErrBadIndex,
+			Err: fmt.Errorf("%d not in 0-%d", i, len(g.Children)-1)}
+	}
+	labels := svg.tagPaths()
+	c = g.Children[i]
+	untag(&c)
+	g.Children = append(g.Children[:i], g.Children[i+1:]...)
+	g.shiftNodes(i, -1)
+	return c, svg.edited(labels)
+}
+
+// Move g.Children[from] so that it becomes g.Children[to].
+func (svg *SVG) MoveChild(g *Group, from, to int) (err error) {
+	err = svg.checkGroup("MoveChild", g)
+	if err != nil {
+		return err
+	}
+	n := len(g.Children)
+	if from < 0 || from >= n || to < 0 || to >= n {
+		return &Error{Op: "MoveChild", Name: g.ID, Kind: ErrBadIndex,
+			Err: fmt.Errorf("%d or %d not in 0-%d", from, to, n-1)}
+	}
+	labels := svg.tagPaths()
+	c := g.Children[from]
+	if from < to {
+		copy(g.Children[from:to], g.Children[from+1:to+1])
+	} else {
+		copy(g.Children[to+1:from+1], g.Children[to:from])
+	}
+	g.Children[to] = c
+	g.shiftNodes(from, -1)
+	g.shiftNodes(to, 1)
+	return svg.edited(labels)
+}
+
+// Put the children g.Children[start:end] into a new group, which
+// takes the place of those children in g. The attributes of the new
+// group, such as Element and Position, are copied from attrs, and its
+// ID is set by the renumbering. The return value points to the new
+// group.
+func (svg *SVG) WrapInGroup(g *Group, start, end int, attrs Group) (wrapper *Group, err error) {
+	err = svg.checkGroup("WrapInGroup", g)
+	if err != nil {
+		return nil, err
+	}
+	if start < 0 || end > len(g.Children) || start >= end {
+		return nil, &Error{Op: "WrapInGroup", Name: g.ID, Kind: ErrBadIndex,
+			Err: fmt.Errorf("bad range %d-%d of 0-%d", start, end, len(g.Children))}
+	}
+	labels := svg.tagPaths()
+	var c Child
+	c.IsGroup = true
+	c.Group = attrs
+	c.Group.node = nil
+	c.Group.Children = append([]Child{}, g.Children[start:end]...)
+	// The unknown elements between the children which are wrapped go
+	// into the new group with them.
+	c.Group.ExtraNodes = nil
+	var nodes []Node
+	for _, n := range g.ExtraNodes {
+		switch {
+		case n.After > start && n.After < end:
+			n.After -= start
+			c.Group.ExtraNodes = append(c.Group.ExtraNodes, n)
+			continue
+		case n.After >= end:
+			n.After -= end - start - 1
+		}
+		nodes = append(nodes, n)
+	}
+	g.ExtraNodes = nodes
+	children := append([]Child{}, g.Children[:start]...)
+	children = append(children, c)
+	children = append(children, g.Children[end:]...)
+	g.Children = children
+	err = svg.edited(labels)
+	if err != nil {
+		return nil, err
+	}
+	return &g.Children[start].Group, nil
+}
+
+// Replace the group g with its children in the group which contains
+// it. The group g must be a descendant of the base group of svg.
+func (svg *SVG) Unwrap(g *Group) (err error) {
+	err = svg.checkGroup("Unwrap", g)
+	if err != nil {
+		return err
+	}
+	parent := g.Parent()
+	if sameGroup(g, svg.BaseGroup()) {
+		return &Error{Op: "Unwrap", Name: g.ID, Kind: ErrNotInTree,
+			Err: fmt.Errorf("cannot unwrap the base group")}
+	}
+	i := g.IndexInParent()
+	labels := svg.tagPaths()
+	k := len(g.Children)
+	for j := range parent.ExtraNodes {
+		if parent.ExtraNodes[j].After > i {
+			parent.ExtraNodes[j].After += k - 1
+		}
+	}
+	for _, n := range g.ExtraNodes {
+		if n.After > k {
+			n.After = k
+		}
+		n.After += i
+		parent.ExtraNodes = append(parent.ExtraNodes, n)
+	}
+	children := append([]Child{}, parent.Children[:i]...)
+	children = append(children, parent.Children[i].Group.Children...)
+	children = append(children, parent.Children[i+1:]...)
+	parent.Children = children
+	return svg.edited(labels)
+}
+
+// Move the unknown elements of g after n children have been put in at
+// g.Children[i], or -n taken out from there if n is negative. Each
+// unknown element stays before the child which it was before.
+func (g *Group) shiftNodes(i, n int) {
+	for j := range g.ExtraNodes {
+		if a := g.ExtraNodes[j].After; a > i || n > 0 && a == i {
+			g.ExtraNodes[j].After += n
+		}
+	}
+}
+
+// One part of a permutation, such as "1-2=3-4" or "5=6".
+var permutationRe = regexp.MustCompile(`^([0-9]+)(?:-([0-9]+))?=([0-9]+)(?:-([0-9]+))?$`)
+
+// Read a permutation of n strokes from s, which is a comma-separated
+// list of parts such as "1-2=3-4", meaning that strokes 1 to 2 are
+// replaced by strokes 3 to 4, or "5=6", meaning that stroke 5 is
+// replaced by stroke 6. The strokes are numbered from one. The
+// permutation is returned as perm[i] = j, meaning that stroke j+1 goes
+// to place i+1, counting from zero. Strokes which are not mentioned
+// stay where they are. The error is ErrBadPermutation if a part is
+// malformed, if the two ranges of a part are of different sizes, or if
+// s does not give each stroke exactly one place.
+func ParsePermutation(s string, n int) (perm []int, err error) {
+	bad := func(format string, a ...any) error {
+		return &Error{Op: "ParsePermutation", Name: s, Kind: ErrBadPermutation,
+			Err: fmt.Errorf(format, a...)}
+	}
+	perm = make([]int, n)
+	for i := range perm {
+		perm[i] = i
+	}
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		d := permutationRe.FindStringSubmatch(part)
+		if d == nil {
+			return nil, bad("%q is not of the form a-b=c-d", part)
+		}
+		var v [4]int
+		for i, digits := range d[1:] {
+			if digits == "" {
+				// A single stroke rather than a range.
+				v[i] = v[i-1]
+				continue
+			}
+			v[i], err = strconv.Atoi(digits)
+			if err != nil {
+				return nil, bad("%q: %s", part, err)
+			}
+			if v[i] < 1 || v[i] > n {
+				return nil, bad("%q: %d not in 1-%d", part, v[i], n)
+			}
+		}
+		if v[1]-v[0] != v[3]-v[2] {
+			return nil, bad("sizes of ranges in %q differ, %d != %d",
+				part, v[1]-v[0]+1, v[3]-v[2]+1)
+		}
+		if v[1] < v[0] {
+			return nil, bad("%q: range goes backwards", part)
+		}
+		for i := 0; i <= v[1]-v[0]; i++ {
+			perm[v[0]+i-1] = v[2] + i - 1
+		}
+	}
+	used := make([]bool, n)
+	for i, j := range perm {
+		if used[j] {
+			return nil, bad("stroke %d goes to more than one place, including %d", j+1, i+1)
+		}
+		used[j] = true
+	}
+	return perm, nil
+}
+
+// Put the strokes of svg into a new order, where perm is a permutation
+// as given by ParsePermutation, so that stroke perm[i]+1 becomes stroke
+// i+1. Whole paths are moved, with their types and shapes, and each
+// label moves with its stroke. The order of the children of a group
+// changes, but no stroke may move to a place in another group, so the
+// groups stay as they are. The error is ErrCrossesGroup if a stroke
+// would move to another group, and ErrBadPermutation if perm is not a
+// permutation of the strokes.
+func (svg *SVG) ReorderStrokes(perm []int) (err error) {
+	base, err := svg.FindBaseGroup()
+	if err != nil {
+		return err
+	}
+	paths := base.GetPaths()
+	if len(perm) != len(paths) {
+		return &Error{Op: "ReorderStrokes", Kind: ErrBadPermutation,
+			Err: fmt.Errorf("%d places for %d strokes", len(perm), len(paths))}
+	}
+	// After linking, the strokes are in the same group if they are
+	// held by children of the same group.
+	svg.Link()
+	used := make([]bool, len(paths))
+	for i, j := range perm {
+		if j < 0 || j >= len(paths) || used[j] {
+			return &Error{Op: "ReorderStrokes", Kind: ErrBadPermutation,
+				Err: fmt.Errorf("bad place %d for stroke %d", j, i)}
+		}
+		used[j] = true
+		if paths[i].child().parent != paths[j].child().parent {
+			return &Error{Op: "ReorderStrokes", Name: paths[j].ID, Kind: ErrCrossesGroup,
+				Err: fmt.Errorf("cannot move to the place of %s", paths[i].ID)}
+		}
+	}
+	labels := svg.tagPaths()
+	moved := make([]Path, len(paths))
+	for i, j := range perm {
+		moved[i] = *paths[j]
+	}
+	for i, p := range paths {
+		*p = moved[i]
+	}
+	return svg.edited(labels)
+}
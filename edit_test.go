@@ -0,0 +1,189 @@
+package kvg
+
+import (
+	"errors"
+	"testing"
+)
+
+// The transforms of the stroke number labels of svg.
+func transforms(svg *SVG) (ts []string) {
+	for _, c := range svg.Groups[1].Children {
+		ts = append(ts, c.Text.Transform.String())
+	}
+	return ts
+}
+
+// Check that the paths and labels of svg are numbered in order.
+func checkNumbers(t *testing.T, svg *SVG, n int) {
+	t.Helper()
+	paths := svg.GetPaths()
+	if len(paths) != n || len(svg.Groups[1].Children) != n {
+		t.Fatalf("Expected %d paths and labels, got %d and %d",
+			n, len(paths), len(svg.Groups[1].Children))
+	}
+	for i, p := range paths {
+		num, err := ParsePathID(p.ID)
+		if err != nil || num != int64(i+1) {
+			t.Errorf("Path %d has ID %s", i+1, p.ID)
+		}
+		label := string(svg.Groups[1].Children[i].Text.Content)
+		if label != paths[i].ID[len("kvg:08475-s"):] {
+			t.Errorf("Label %d is %s", i+1, label)
+		}
+	}
+}
+
+func TestEdit(t *testing.T) {
+	svg, err := ReadKanjiFile(bin() + "/t/08475.svg")
+	if err != nil {
+		t.Fatalf("Error reading: %s", err)
+	}
+	before := transforms(&svg)
+	paths := svg.GetPaths()
+	s5 := paths[4]
+	g4 := s5.Parent()
+	// Swap the two strokes of g4, and check that the labels follow.
+	err = svg.MoveChild(g4, 1, 0)
+	if err != nil {
+		t.Fatalf("Error moving: %s", err)
+	}
+	checkNumbers(t, &svg, 12)
+	after := transforms(&svg)
+	if after[3] != before[4] || after[4] != before[3] {
+		t.Errorf("Labels did not move with strokes: %v", after)
+	}
+	d := svg.GetPaths()[3].D
+	if d != "M24.56,45.46c2.84,1.36,7.33,5.58,8.04,7.69" {
+		t.Errorf("Wrong path moved: %s", d)
+	}
+	// Remove the first stroke.
+	base := svg.BaseGroup()
+	g1 := &base.Children[0].Group
+	c, err := svg.RemoveChild(g1, 0)
+	if err != nil {
+		t.Fatalf("Error removing: %s", err)
+	}
+	checkNumbers(t, &svg, 11)
+	if transforms(&svg)[0] != before[1] {
+		t.Errorf("Label of removed stroke kept")
+	}
+	// Put it back at the end of the kanji, where it gets a new label.
+	err = svg.InsertChild(base, len(base.Children), c)
+	if err != nil {
+		t.Fatalf("Error inserting: %s", err)
+	}
+	checkNumbers(t, &svg, 12)
+	last := transforms(&svg)[11]
+	if last != "matrix(1 0 0 1 14.5 26.7)" {
+		t.Errorf("Wrong new label %s", last)
+	}
+	// Wrap the remaining strokes of g1 in a group, then unwrap it.
+	g1 = &svg.BaseGroup().Children[0].Group
+	n := len(g1.Children)
+	wrapper, err := svg.WrapInGroup(g1, 0, n, Group{Element: "丨"})
+	if err != nil {
+		t.Fatalf("Error wrapping: %s", err)
+	}
+	if wrapper.ID != "kvg:08475-g2" || wrapper.Parent().ID != "kvg:08475-g1" ||
+		len(wrapper.Children) != n {
+		t.Errorf("Bad wrapper group %s", wrapper.ID)
+	}
+	checkNumbers(t, &svg, 12)
+	wrapped := transforms(&svg)
+	err = svg.Unwrap(wrapper)
+	if err != nil {
+		t.Fatalf("Error unwrapping: %s", err)
+	}
+	checkNumbers(t, &svg, 12)
+	for i, tr := range transforms(&svg) {
+		if tr != wrapped[i] {
+			t.Errorf("Label %d changed on unwrapping", i+1)
+		}
+	}
+	if len(svg.BaseGroup().Children[0].Group.Children) != n {
+		t.Errorf("Unwrapped children lost")
+	}
+	// Errors
+	_, err = svg.RemoveChild(g1, 10)
+	if !errors.Is(err, ErrBadIndex) {
+		t.Errorf("Expected ErrBadIndex, got %v", err)
+	}
+	err = svg.MoveChild(&svg.Groups[1], 0, 1)
+	if !errors.Is(err, ErrNotInTree) {
+		t.Errorf("Expected ErrNotInTree, got %v", err)
+	}
+	err = svg.Unwrap(svg.BaseGroup())
+	if !errors.Is(err, ErrNotInTree) {
+		t.Errorf("Expected ErrNotInTree unwrapping the base group, got %v", err)
+	}
+}
+
+func TestParsePermutation(t *testing.T) {
+	perm, err := ParsePermutation("1-2=3-4,3-4=1-2", 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []int{2, 3, 0, 1, 4}
+	for i := range want {
+		if perm[i] != want[i] {
+			t.Fatalf("Expected %v, got %v", want, perm)
+		}
+	}
+	for _, bad := range []string{"", "x", "1-2=3", "1=6", "0=1", "1=2", "2-1=1-2"} {
+		_, err = ParsePermutation(bad, 5)
+		if !errors.Is(err, ErrBadPermutation) {
+			t.Errorf("%q: expected ErrBadPermutation, got %v", bad, err)
+		}
+	}
+}
+
+func TestReorderStrokes(t *testing.T) {
+	svg, err := ReadKanjiFile(bin() + "/t/08475.svg")
+	if err != nil {
+		t.Fatalf("Error reading: %s", err)
+	}
+	before := transforms(&svg)
+	var old []Path
+	for _, p := range svg.GetPaths() {
+		old = append(old, *p)
+	}
+	// Rotate the three strokes of the right of 癶.
+	perm, err := ParsePermutation("6-7=7-8,8=6", 12)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = svg.ReorderStrokes(perm)
+	if err != nil {
+		t.Fatalf("Error reordering: %s", err)
+	}
+	checkNumbers(t, &svg, 12)
+	after := transforms(&svg)
+	for i, p := range svg.GetPaths() {
+		o := old[perm[i]]
+		if p.D != o.D || p.Type != o.Type {
+			t.Errorf("Stroke %d is not the old stroke %d", i+1, perm[i]+1)
+		}
+		if after[i] != before[perm[i]] {
+			t.Errorf("Label %d did not move with its stroke", i+1)
+		}
+	}
+	if svg.GetPaths()[7].Parent().ID != "kvg:08475-g5" {
+		t.Errorf("Stroke moved out of its group")
+	}
+	// Stroke 9 is in 天 but strokes 10 to 12 are in 大.
+	perm, err = ParsePermutation("9=10,10=9", 12)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = svg.ReorderStrokes(perm)
+	if !errors.Is(err, ErrCrossesGroup) {
+		t.Errorf("Expected ErrCrossesGroup, got %v", err)
+	}
+	if transforms(&svg)[8] != after[8] {
+		t.Errorf("Refused reorder changed the labels")
+	}
+	err = svg.ReorderStrokes([]int{0, 1})
+	if !errors.Is(err, ErrBadPermutation) {
+		t.Errorf("Expected ErrBadPermutation, got %v", err)
+	}
+}
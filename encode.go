@@ -0,0 +1,291 @@
+package kvg
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"sort"
+)
+
+// Writing the XML of the KanjiVG files.
+//
+// The Encoder writes the elements one at a time rather than going
+// through encoding/xml, so that the layout is exactly that of the
+// KanjiVG files: each element is on its own line, indented with tabs
+// by the number of groups it is in, except that the base group and
+// everything in it are indented one less than that, so the base group
+// is level with the StrokePaths group which contains it. A path with
+// no content is written as <path .../>, but a group is always written
+// as <g ...></g>.
+
+// The layout of the XML written by an Encoder.
+type Format struct {
+	// The string used for one level of indentation.
+	Indent string
+	// If true, the groups and paths in the StrokePaths group are
+	// indented one level less, as in the KanjiVG files.
+	FlushBase bool
+	// The names of the elements which are written as <x/> when they
+	// have no content, rather than as <x></x>.
+	SelfClose map[string]bool
+	// The order of the attributes of each element, given by element
+	// name, such as "g", and then attribute name with its prefix,
+	// such as "kvg:element". Attributes which are not listed come
+	// after the listed ones, in the order they were read, followed
+	// by any which have been set since.
+	AttrOrder map[string][]string
+}
+
+// The format of the KanjiVG files.
+var KanjiVGFormat = Format{
+	Indent:    "\t",
+	FlushBase: true,
+	SelfClose: map[string]bool{"path": true},
+}
+
+// Writes SVGs to an output stream.
+type Encoder struct {
+	w io.Writer
+	// The layout of the output, which is KanjiVGFormat unless it is
+	// changed.
+	Format Format
+	buf    bytes.Buffer
+}
+
+// Make an encoder which writes to w in the KanjiVG format.
+func NewEncoder(w io.Writer) (e *Encoder) {
+	return &Encoder{w: w, Format: KanjiVGFormat}
+}
+
+// Write svg, starting from the <svg> element and ending with a
+// newline. This does not write Heading, or renumber the IDs, which
+// MarshalKanji does.
+func (e *Encoder) Encode(svg *SVG) (err error) {
+	e.buf.Reset()
+	e.start(0, "svg", svg.attrs(), false)
+	e.buf.WriteByte('\n')
+	for i := 0; i <= len(svg.Groups); i++ {
+		e.nodes(svg.ExtraNodes, i, len(svg.Groups), 0)
+		if i < len(svg.Groups) {
+			e.group(&svg.Groups[i], 0, 0, i == 0)
+		}
+	}
+	e.end(0, "svg")
+	_, err = e.w.Write(e.buf.Bytes())
+	return err
+}
+
+// Write the indentation for an element at the given level.
+func (e *Encoder) indent(level int) {
+	for i := 0; i < level; i++ {
+		e.buf.WriteString(e.Format.Indent)
+	}
+}
+
+// Write a start tag. If empty is true, the element has no content,
+// and the tag is closed if the format says so. The return value is
+// true if the tag was closed.
+func (e *Encoder) start(level int, name string, attrs []xml.Attr, empty bool) (closed bool) {
+	e.indent(level)
+	e.buf.WriteByte('<')
+	e.buf.WriteString(name)
+	for _, a := range e.order(name, attrs) {
+		e.buf.WriteByte(' ')
+		e.buf.WriteString(a.Name.Local)
+		e.buf.WriteString(`="`)
+		xml.EscapeText(&e.buf, []byte(a.Value))
+		e.buf.WriteByte('"')
+	}
+	if empty && e.Format.SelfClose[name] {
+		e.buf.WriteString("/>")
+		return true
+	}
+	e.buf.WriteByte('>')
+	return false
+}
+
+// Write an end tag and a newline. The level is zero for an end tag on
+// the same line as its start tag.
+func (e *Encoder) end(level int, name string) {
+	e.indent(level)
+	e.buf.WriteString("</")
+	e.buf.WriteString(name)
+	e.buf.WriteString(">\n")
+}
+
+// Put attrs into the order given for element name by the format.
+func (e *Encoder) order(name string, attrs []xml.Attr) []xml.Attr {
+	order := e.Format.AttrOrder[name]
+	if len(order) == 0 {
+		return attrs
+	}
+	rank := make(map[string]int, len(order))
+	for i, n := range order {
+		rank[n] = i - len(order)
+	}
+	sorted := append([]xml.Attr{}, attrs...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return rank[sorted[i].Name.Local] < rank[sorted[j].Name.Local]
+	})
+	return sorted
+}
+
+// Write group g, which is inside depth other groups. The indentation
+// of g is level. If strokes is true, g is the StrokePaths group or
+// inside it.
+func (e *Encoder) group(g *Group, depth, level int, strokes bool) {
+	childLevel := depth + 1
+	if strokes && e.Format.FlushBase {
+		childLevel = depth
+	}
+	empty := len(g.Children) == 0 && len(g.ExtraNodes) == 0
+	if e.start(level, "g", g.attrs(), empty) {
+		e.buf.WriteByte('\n')
+		return
+	}
+	if empty {
+		e.end(0, "g")
+		return
+	}
+	e.buf.WriteByte('\n')
+	for i := 0; i <= len(g.Children); i++ {
+		e.nodes(g.ExtraNodes, i, len(g.Children), childLevel)
+		if i == len(g.Children) {
+			break
+		}
+		c := &g.Children[i]
+		switch {
+		case c.IsGroup:
+			e.group(&c.Group, depth+1, childLevel, strokes)
+		case c.IsText:
+			e.text(&c.Text, childLevel)
+		default:
+			e.path(&c.Path, childLevel)
+		}
+	}
+	e.end(level, "g")
+}
+
+// Write the nodes which come before the i'th of n elements, which are
+// those whose After is i, and when i is n, also those past the end.
+func (e *Encoder) nodes(nodes []Node, i, n, level int) {
+	for j := range nodes {
+		if nodes[j].After == i || i == n && nodes[j].After > n {
+			e.node(&nodes[j], level)
+		}
+	}
+}
+
+// Write a path.
+func (e *Encoder) path(p *Path, level int) {
+	if !e.start(level, "path", p.attrs(), true) {
+		e.end(0, "path")
+		return
+	}
+	e.buf.WriteByte('\n')
+}
+
+// Write a text on one line.
+func (e *Encoder) text(t *Text, level int) {
+	if e.start(level, "text", t.attrs(), len(t.Content) == 0) {
+		e.buf.WriteByte('\n')
+		return
+	}
+	xml.EscapeText(&e.buf, t.Content)
+	e.end(0, "text")
+}
+
+// Write an unknown element, with its contents as they were read.
+func (e *Encoder) node(n *Node, level int) {
+	name := n.XMLName.Local
+	if e.start(level, name, n.Attrs, len(n.Inner) == 0) {
+		e.buf.WriteByte('\n')
+		return
+	}
+	e.buf.Write(n.Inner)
+	e.end(0, name)
+}
+
+// Add the attribute name="value" to attrs, unless omit is true.
+func addAttr(attrs []xml.Attr, name, value string, omit bool) []xml.Attr {
+	if omit {
+		return attrs
+	}
+	return append(attrs, xml.Attr{Name: xml.Name{Local: name}, Value: value})
+}
+
+// Add the attribute name="value" to attrs if value is not empty.
+func addString(attrs []xml.Attr, name, value string) []xml.Attr {
+	return addAttr(attrs, name, value, len(value) == 0)
+}
+
+// Add the attribute name="true" to attrs if value is true.
+func addBool(attrs []xml.Attr, name string, value bool) []xml.Attr {
+	return addAttr(attrs, name, "true", !value)
+}
+
+// Put attrs into the order in which they were read, given by the
+// names in read. Attributes which were not read, such as one which has
+// been set since, keep their usual place after the ones which were.
+func readOrder(attrs []xml.Attr, read []string) []xml.Attr {
+	if len(read) == 0 {
+		return attrs
+	}
+	rank := make(map[string]int, len(read))
+	for i, n := range read {
+		rank[n] = i + 1
+	}
+	sorted := append([]xml.Attr{}, attrs...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		ri, rj := rank[sorted[i].Name.Local], rank[sorted[j].Name.Local]
+		if ri == 0 || rj == 0 {
+			return ri != 0 && rj == 0
+		}
+		return ri < rj
+	})
+	return sorted
+}
+
+// The attributes of svg, in the usual order or the order they were
+// read.
+func (svg *SVG) attrs() (attrs []xml.Attr) {
+	attrs = addAttr(attrs, "xmlns", svg.XMLNS, false)
+	attrs = addAttr(attrs, "width", svg.Width, false)
+	attrs = addAttr(attrs, "height", svg.Height, false)
+	attrs = addString(attrs, "viewBox", svg.ViewBox)
+	return readOrder(append(attrs, svg.ExtraAttrs...), svg.attrOrder)
+}
+
+// The attributes of g, in the usual order or the order they were read.
+func (g *Group) attrs() (attrs []xml.Attr) {
+	attrs = addString(attrs, "id", g.ID)
+	attrs = addString(attrs, "kvg:element", g.Element)
+	attrs = addString(attrs, "kvg:part", string(g.Part))
+	attrs = addBool(attrs, "kvg:variant", g.Variant)
+	attrs = addString(attrs, "kvg:number", g.Number)
+	attrs = addString(attrs, "kvg:original", g.Original)
+	attrs = addBool(attrs, "kvg:partial", g.Partial)
+	attrs = addString(attrs, "kvg:tradForm", g.TradForm)
+	attrs = addString(attrs, "kvg:position", string(g.Position))
+	attrs = addString(attrs, "kvg:radical", string(g.Radical))
+	attrs = addString(attrs, "kvg:phon", g.Phon)
+	attrs = addString(attrs, "kvg:radicalForm", g.RadicalForm)
+	attrs = addString(attrs, "style", g.Style)
+	return readOrder(append(attrs, g.ExtraAttrs...), g.attrOrder)
+}
+
+// The attributes of p, in the usual order or the order they were read.
+func (p *Path) attrs() (attrs []xml.Attr) {
+	attrs = addAttr(attrs, "id", p.ID, false)
+	attrs = addString(attrs, "kvg:type", p.Type)
+	attrs = addAttr(attrs, "d", p.D, false)
+	attrs = addString(attrs, "class", p.Class)
+	return readOrder(append(attrs, p.ExtraAttrs...), p.attrOrder)
+}
+
+// The attributes of t, in the usual order or the order they were read.
+func (t *Text) attrs() (attrs []xml.Attr) {
+	attrs = addString(attrs, "transform", t.Transform.String())
+	attrs = addString(attrs, "class", t.Class)
+	return readOrder(append(attrs, t.ExtraAttrs...), t.attrOrder)
+}
@@ -0,0 +1,55 @@
+package kvg
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strings"
+	"testing"
+)
+
+func TestEncoder(t *testing.T) {
+	svg, err := ReadKanjiFile(bin() + "/t/08475.svg")
+	if err != nil {
+		t.Fatalf("Error reading: %s", err)
+	}
+	// Things which the string replacements of the old writer broke.
+	base := svg.BaseGroup()
+	base.ExtraAttrs = append(base.ExtraAttrs, xml.Attr{
+		Name:  xml.Name{Local: "data-note"},
+		Value: "\t<g></path>",
+	})
+	var buf bytes.Buffer
+	e := NewEncoder(&buf)
+	e.Format = Format{
+		Indent:    "  ",
+		SelfClose: map[string]bool{"g": true},
+		AttrOrder: map[string][]string{"path": {"d", "kvg:type"}},
+	}
+	svg.Groups[0].Children[0].Group.Children[0].Group.Children = nil
+	err = e.Encode(&svg)
+	if err != nil {
+		t.Fatalf("Error encoding: %s", err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"\n  <g id=\"kvg:08475\" kvg:element=\"葵\" data-note=\"&#x9;&lt;g&gt;&lt;/path&gt;\">\n",
+		"\n    <g id=\"kvg:08475-g1\" kvg:element=\"艹\" kvg:variant=\"true\" kvg:original=\"艸\" kvg:position=\"top\" kvg:radical=\"general\"/>\n",
+		"\n          <path d=\"M24.56,45.46c2.84,1.36,7.33,5.58,8.04,7.69\" kvg:type=\"㇔\" id=\"kvg:08475-s5\"></path>\n",
+		"\n  <text transform=\"matrix(1 0 0 1 14.25 23.25)\">1</text>\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Output does not contain %q", want)
+		}
+	}
+	if !strings.HasSuffix(out, ">12</text>\n</g>\n</svg>\n") {
+		t.Errorf("Bad ending:\n%s", out)
+	}
+	again, err := ParseKanji(buf.Bytes())
+	if err != nil {
+		t.Fatalf("Error parsing output: %s", err)
+	}
+	note := again.BaseGroup().ExtraAttrs
+	if len(note) != 1 || note[0].Value != "\t<g></path>" {
+		t.Errorf("Attribute value not kept: %v", note)
+	}
+}
@@ -0,0 +1,86 @@
+package kvg
+
+import (
+	"errors"
+	"fmt"
+)
+
+// The kinds of error returned by the functions of this package. Use
+// errors.Is to test an error against these, for example
+//
+//	if errors.Is(err, kvg.ErrBadFileName) {
+//
+// since the returned value is an *Error which also carries the name
+// which caused the problem.
+var (
+	// The file name does not have the form of a KanjiVG file name,
+	// such as "08475.svg" or "08475-Kaisho.svg".
+	ErrBadFileName = errors.New("bad file name")
+	// An ID, either a hexadecimal kanji number, an XML id, or a
+	// base name, is malformed.
+	ErrBadID = errors.New("bad ID")
+	// The SVG could not be converted into XML.
+	ErrMarshal = errors.New("marshal failure")
+	// The SVG does not contain the base group of the kanji.
+	ErrNoBaseGroup = errors.New("missing base group")
+	// The directory of KanjiVG files was not given or is not a
+	// directory.
+	ErrNoCorpus = errors.New("no corpus directory")
+	// The group being edited is not part of the strokes of the SVG.
+	ErrNotInTree = errors.New("group not in tree")
+	// The position given to an editing method is out of range.
+	ErrBadIndex = errors.New("index out of range")
+	// A namespace prefix in a file is not declared.
+	ErrNamespace = errors.New("undeclared namespace prefix")
+	// An attribute such as kvg:position has a value which is not in
+	// the vocabulary.
+	ErrUnknownValue = errors.New("unknown attribute value")
+	// A stroke is drawn the opposite way to what its kvg:type says.
+	ErrBackwards = errors.New("stroke drawn backwards")
+	// A stroke-number label is missing, misplaced or not a number.
+	ErrBadLabel = errors.New("bad stroke-number label")
+	// A permutation of the strokes is malformed or does not give
+	// each stroke one new place.
+	ErrBadPermutation = errors.New("bad permutation")
+	// An edit would move a stroke out of the group it is in.
+	ErrCrossesGroup = errors.New("stroke moved out of its group")
+	// The viewBox attribute is not four numbers.
+	ErrBadViewBox = errors.New("bad viewBox")
+)
+
+// Error is the type of the errors returned by this package. Kind is
+// one of the Err values above, and Err is the underlying error, if
+// any, for example the error from strconv or encoding/xml.
+type Error struct {
+	// The function which failed.
+	Op string
+	// The file name or ID which caused the error.
+	Name string
+	// The kind of error.
+	Kind error
+	// The underlying error, or nil.
+	Err error
+}
+
+func (e *Error) Error() string {
+	s := e.Op
+	if len(e.Name) > 0 {
+		s += fmt.Sprintf(" %q", e.Name)
+	}
+	s += ": " + e.Kind.Error()
+	if e.Err != nil {
+		s += ": " + e.Err.Error()
+	}
+	return s
+}
+
+// Unwrap returns the underlying error.
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether target is the kind of e, so that errors.Is(err,
+// ErrBadID) and so on work.
+func (e *Error) Is(target error) bool {
+	return target == e.Kind
+}
@@ -0,0 +1,370 @@
+package kvg
+
+import (
+	"math"
+	"strings"
+)
+
+// Converting paths to a form which is easier to compute with.
+
+// Make a copy of p with all of the commands converted to absolute
+// ones, for example "M20.5,23.7c2.92,0.68..." becomes
+// "M20.5,23.7C23.42,24.38...".
+func (p SVGPath) ToAbsolute() SVGPath {
+	return p.convert(true)
+}
+
+// Make a copy of p in which all the commands are absolute, and each
+// command other than moveto and closepath is a cubic Bézier C or a
+// quadratic Bézier Q with its control points given. Smooth curves S
+// and T are turned into C and Q by reflecting the previous control
+// point, lines L, H and V, and the line drawn by a closepath, become
+// straight cubics with their control points a third and two thirds of
+// the way along, and elliptical arcs A are approximated by cubics.
+// The result is the same shape as p.
+func (p SVGPath) Expand() (o SVGPath) {
+	var e expander
+	for _, s := range p.ToAbsolute().Subpaths {
+		var os Subpath
+		for _, c := range s.Commands {
+			os.Commands = append(os.Commands, e.expand(c)...)
+		}
+		o.Subpaths = append(o.Subpaths, os)
+	}
+	return o
+}
+
+// The state of the conversion done by Expand.
+type expander struct {
+	// The current point.
+	x, y float64
+	// The start of the current subpath.
+	startX, startY float64
+	// The last control point of the previous command, if it was a
+	// cubic or a quadratic.
+	ctrlX, ctrlY float64
+	cubic, quad  bool
+}
+
+// The control point of a smooth curve, which is the reflection of the
+// previous control point if the previous command was the same kind of
+// curve, and the current point otherwise.
+func (e *expander) reflect(same bool) (x, y float64) {
+	if !same {
+		return e.x, e.y
+	}
+	return 2*e.x - e.ctrlX, 2*e.y - e.ctrlY
+}
+
+// A straight cubic from the current point to x, y.
+func (e *expander) line(x, y float64) Command {
+	dx, dy := x-e.x, y-e.y
+	return Command{Symbol: "C", Params: []float64{
+		e.x + dx/3, e.y + dy/3, e.x + 2*dx/3, e.y + 2*dy/3, x, y,
+	}}
+}
+
+// Convert the absolute command c.
+func (e *expander) expand(c Command) (out []Command) {
+	p := c.Params
+	switch c.Symbol {
+	case "M":
+		out = []Command{{Symbol: "M", Params: []float64{p[0], p[1]}}}
+		e.startX, e.startY = p[0], p[1]
+	case "L":
+		out = []Command{e.line(p[0], p[1])}
+	case "H":
+		out = []Command{e.line(p[0], e.y)}
+	case "V":
+		out = []Command{e.line(e.x, p[0])}
+	case "C":
+		out = []Command{{Symbol: "C", Params: append([]float64{}, p...)}}
+	case "S":
+		x1, y1 := e.reflect(e.cubic)
+		out = []Command{{Symbol: "C", Params: []float64{x1, y1, p[0], p[1], p[2], p[3]}}}
+	case "Q":
+		out = []Command{{Symbol: "Q", Params: append([]float64{}, p...)}}
+	case "T":
+		x1, y1 := e.reflect(e.quad)
+		out = []Command{{Symbol: "Q", Params: []float64{x1, y1, p[0], p[1]}}}
+	case "A":
+		out = arcToCubics(e.x, e.y, p)
+	case "Z":
+		if e.x != e.startX || e.y != e.startY {
+			out = append(out, e.line(e.startX, e.startY))
+		}
+		out = append(out, Command{Symbol: "Z"})
+	}
+	for _, o := range out {
+		switch o.Symbol {
+		case "C":
+			e.ctrlX, e.ctrlY = o.Params[2], o.Params[3]
+			e.x, e.y = o.Params[4], o.Params[5]
+		case "Q":
+			e.ctrlX, e.ctrlY = o.Params[0], o.Params[1]
+			e.x, e.y = o.Params[2], o.Params[3]
+		case "M", "Z":
+			e.x, e.y = e.startX, e.startY
+		}
+	}
+	// Only a curve of the same kind in the source is reflected by S
+	// or T, not the cubics made from lines and arcs.
+	e.cubic = c.Symbol == "C" || c.Symbol == "S"
+	e.quad = c.Symbol == "Q" || c.Symbol == "T"
+	// An arc which is only a point gives no commands, and an arc
+	// with zero radius is a line, but either way it ends at its end
+	// point.
+	if c.Symbol == "A" {
+		e.x, e.y = p[5], p[6]
+	}
+	return out
+}
+
+// The angle from vector u to vector v.
+func angle(ux, uy, vx, vy float64) float64 {
+	return math.Atan2(ux*vy-uy*vx, ux*vx+uy*vy)
+}
+
+// Approximate the elliptical arc from x1, y1 with the parameters of
+// an A command by cubics, each covering at most a quarter of the
+// ellipse. This follows the conversion from endpoint to center
+// parameterization in the implementation notes of the SVG
+// specification.
+func arcToCubics(x1, y1 float64, params []float64) (out []Command) {
+	rx, ry := math.Abs(params[0]), math.Abs(params[1])
+	phi := params[2] * math.Pi / 180
+	large, sweep := params[3] != 0, params[4] != 0
+	x2, y2 := params[5], params[6]
+	if x1 == x2 && y1 == y2 {
+		return nil
+	}
+	if rx == 0 || ry == 0 {
+		e := expander{x: x1, y: y1}
+		return []Command{e.line(x2, y2)}
+	}
+	cos, sin := math.Cos(phi), math.Sin(phi)
+	dx, dy := (x1-x2)/2, (y1-y2)/2
+	x1p := cos*dx + sin*dy
+	y1p := -sin*dx + cos*dy
+	// Make the radii big enough to reach from one end to the other.
+	lambda := x1p*x1p/(rx*rx) + y1p*y1p/(ry*ry)
+	if lambda > 1 {
+		rx *= math.Sqrt(lambda)
+		ry *= math.Sqrt(lambda)
+	}
+	num := rx*rx*ry*ry - rx*rx*y1p*y1p - ry*ry*x1p*x1p
+	den := rx*rx*y1p*y1p + ry*ry*x1p*x1p
+	coef := math.Sqrt(math.Max(0, num/den))
+	if large == sweep {
+		coef = -coef
+	}
+	cxp := coef * rx * y1p / ry
+	cyp := -coef * ry * x1p / rx
+	cx := cos*cxp - sin*cyp + (x1+x2)/2
+	cy := sin*cxp + cos*cyp + (y1+y2)/2
+	theta := angle(1, 0, (x1p-cxp)/rx, (y1p-cyp)/ry)
+	dtheta := angle((x1p-cxp)/rx, (y1p-cyp)/ry, (-x1p-cxp)/rx, (-y1p-cyp)/ry)
+	if !sweep && dtheta > 0 {
+		dtheta -= 2 * math.Pi
+	} else if sweep && dtheta < 0 {
+		dtheta += 2 * math.Pi
+	}
+	n := int(math.Ceil(math.Abs(dtheta) / (math.Pi / 2)))
+	delta := dtheta / float64(n)
+	k := 4.0 / 3.0 * math.Tan(delta/4)
+	// The point and the derivative of the ellipse at angle t.
+	point := func(t float64) (x, y, dx, dy float64) {
+		ct, st := math.Cos(t), math.Sin(t)
+		x = cx + rx*ct*cos - ry*st*sin
+		y = cy + rx*ct*sin + ry*st*cos
+		dx = -rx*st*cos - ry*ct*sin
+		dy = -rx*st*sin + ry*ct*cos
+		return x, y, dx, dy
+	}
+	for i := 0; i < n; i++ {
+		t1 := theta + float64(i)*delta
+		ax, ay, adx, ady := point(t1)
+		bx, by, bdx, bdy := point(t1 + delta)
+		if i == n-1 {
+			bx, by = x2, y2
+		}
+		out = append(out, Command{Symbol: "C", Params: []float64{
+			ax + k*adx, ay + k*ady, bx - k*bdx, by - k*bdy, bx, by,
+		}})
+	}
+	return out
+}
+
+// Make a copy of p which draws the same shape from its end to its
+// start. The subpaths are in the opposite order and each is reversed.
+// Each command is reversed in its own form, so lines stay lines and
+// arcs stay arcs with their sweep flipped, and relative commands stay
+// relative. Smooth curves S and T become C and Q, since the curve they
+// are reflected from comes after them once reversed. A closed subpath
+// starts from its first point, with the line drawn by the closepath
+// made into a line in the mode of the closepath. Reversing twice gives
+// back p, apart from the rounding of the additions and subtractions
+// made for relative commands, and apart from closed subpaths which do
+// not end at their start.
+func (p SVGPath) Reverse() (o SVGPath) {
+	abs := p.ToAbsolute()
+	var at, start, ctrl Point
+	// The kind of the previous curve, for reflecting smooth curves.
+	prev := ""
+	var subpaths []Subpath
+	for si, s := range abs.Subpaths {
+		cs := s.Commands
+		// The point before each command, and the commands with smooth
+		// curves made explicit.
+		from := make([]Point, len(cs))
+		explicit := make([]Command, len(cs))
+		closed := cs[len(cs)-1].Symbol == "Z"
+		for j, c := range cs {
+			from[j] = at
+			q := c.Params
+			n := Command{Symbol: c.Symbol, Params: q}
+			switch c.Symbol {
+			case "M":
+				at = Point{q[0], q[1]}
+				start = at
+			case "Z":
+				at = start
+			case "H":
+				at.X = q[0]
+			case "V":
+				at.Y = q[0]
+			case "S":
+				r := at
+				if prev == "C" {
+					r = at.add(at.sub(ctrl))
+				}
+				n.Symbol = "C"
+				n.Params = []float64{r.X, r.Y, q[0], q[1], q[2], q[3]}
+			case "T":
+				r := at
+				if prev == "Q" {
+					r = at.add(at.sub(ctrl))
+				}
+				n.Symbol = "Q"
+				n.Params = []float64{r.X, r.Y, q[0], q[1]}
+			}
+			switch n.Symbol {
+			case "C":
+				ctrl = Point{n.Params[2], n.Params[3]}
+			case "Q":
+				ctrl = Point{n.Params[0], n.Params[1]}
+			}
+			prev = n.Symbol
+			if c.Symbol != "M" && c.Symbol != "Z" && c.Symbol != "H" && c.Symbol != "V" {
+				at = Point{q[len(q)-2], q[len(q)-1]}
+			}
+			explicit[j] = n
+		}
+		// The mode of each command is that of the command it comes
+		// from in p.
+		relative := func(j int) bool {
+			return !p.Subpaths[si].Commands[j].IsAbsolute()
+		}
+		implicit := func(j int) bool {
+			return j < len(cs) && p.Subpaths[si].Commands[j].Implicit
+		}
+		last := len(cs) - 1
+		if closed {
+			last--
+		}
+		end := at
+		if closed {
+			end = from[len(cs)-1]
+		}
+		rs := []Command{{Symbol: "M", Params: []float64{end.X, end.Y}}}
+		if relative(0) {
+			rs[0].Symbol = "m"
+		}
+		if closed {
+			rs[0].Params = []float64{start.X, start.Y}
+			if end != start {
+				l := Command{Symbol: "L", Params: []float64{end.X, end.Y}}
+				if relative(len(cs) - 1) {
+					l.Symbol = "l"
+				}
+				rs = append(rs, l)
+			}
+		}
+		for j := last; j >= 1; j-- {
+			c := explicit[j]
+			q := c.Params
+			to := from[j]
+			var n Command
+			switch c.Symbol {
+			case "L", "Z":
+				n = Command{Symbol: "L", Params: []float64{to.X, to.Y}}
+			case "H":
+				n = Command{Symbol: "H", Params: []float64{to.X}}
+			case "V":
+				n = Command{Symbol: "V", Params: []float64{to.Y}}
+			case "C":
+				n = Command{Symbol: "C", Params: []float64{q[2], q[3], q[0], q[1], to.X, to.Y}}
+			case "Q":
+				n = Command{Symbol: "Q", Params: []float64{q[0], q[1], to.X, to.Y}}
+			case "A":
+				n = Command{Symbol: "A", Params: []float64{q[0], q[1], q[2], q[3], 1 - q[4], to.X, to.Y}}
+			}
+			if relative(j) {
+				n.Symbol = strings.ToLower(n.Symbol)
+			}
+			// The command before this one is the one after it in
+			// p, so it is implicit if that one was.
+			if j == last {
+				n.Implicit = implicit(1)
+			} else {
+				n.Implicit = implicit(j + 1)
+			}
+			rs = append(rs, n)
+		}
+		if closed {
+			rs = append(rs, Command{Symbol: "Z"})
+			if relative(len(cs) - 1) {
+				rs[len(rs)-1].Symbol = "z"
+			}
+		}
+		subpaths = append([]Subpath{{rs}}, subpaths...)
+	}
+	o.Subpaths = subpaths
+	return o.fromAbsolute()
+}
+
+// Make a copy of p, whose parameters are all absolute, with the
+// parameters of the commands whose symbols are lower case made
+// relative.
+func (p SVGPath) fromAbsolute() (o SVGPath) {
+	var at, start Point
+	for _, s := range p.Subpaths {
+		var os Subpath
+		for _, c := range s.Commands {
+			lower := strings.ToLower(c.Symbol)
+			coords := coordinates[lower]
+			params := append([]float64{}, c.Params...)
+			before := at
+			switch lower {
+			case "z":
+				at = start
+			case "h":
+				at.X = params[0]
+			case "v":
+				at.Y = params[0]
+			default:
+				at = Point{params[len(params)-2], params[len(params)-1]}
+			}
+			if lower == "m" {
+				start = at
+			}
+			if !c.IsAbsolute() {
+				shift(params, coords.x, -before.X)
+				shift(params, coords.y, -before.Y)
+			}
+			os.Commands = append(os.Commands, Command{Symbol: c.Symbol, Params: params, Implicit: c.Implicit})
+		}
+		o.Subpaths = append(o.Subpaths, os)
+	}
+	return o
+}
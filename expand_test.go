@@ -0,0 +1,68 @@
+package kvg
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestExpand(t *testing.T) {
+	raw := "M10,10h10v10l-10,0zm5,5s10,0,10,10s0,10,10,10q5,0,5,5t5,5t5,5"
+	path, err := PathParser(raw)
+	if err != nil {
+		t.Fatalf("Error parsing %s: %s", raw, err)
+	}
+	got := DefaultPathFormat.Encode(path.ToAbsolute())
+	want := "M10,10H20V20L10,20ZM15,15S25,15,25,25S25,35,35,35Q40,35,40,40T45,45T50,50"
+	if got != want {
+		t.Errorf("Absolute %s gave\n%s, expected\n%s", raw, got, want)
+	}
+	got = PathFormat{Precision: 3}.Encode(path.Expand())
+	want = "M10,10C13.333,10,16.667,10,20,10C20,13.333,20,16.667,20,20" +
+		"C16.667,20,13.333,20,10,20C10,16.667,10,13.333,10,10Z" +
+		"M15,15C15,15,25,15,25,25C25,35,25,35,35,35" +
+		"Q40,35,40,40Q40,45,45,45Q50,45,50,50"
+	if got != want {
+		t.Errorf("Expanded %s gave\n%s, expected\n%s", raw, got, want)
+	}
+	// A semicircle of radius 10 around 10,0 is two quarter circles.
+	raw = "M0,0a10,10,0,0,1,20,0"
+	path, _ = PathParser(raw)
+	cs := path.Expand().Subpaths[0].Commands
+	if len(cs) != 3 {
+		t.Fatalf("Expanded %s to %d commands", raw, len(cs))
+	}
+	x, y := 0.0, 0.0
+	for _, c := range cs[1:] {
+		p := c.Params
+		// The middle of the cubic should be on the circle.
+		mx := (x + 3*p[0] + 3*p[2] + p[4]) / 8
+		my := (y + 3*p[1] + 3*p[3] + p[5]) / 8
+		if r := math.Hypot(mx-10, my); math.Abs(r-10) > 0.01 {
+			t.Errorf("Middle of %v is %g from the center", c, r)
+		}
+		if my > 0 {
+			t.Errorf("Arc %s went the wrong way: %v", raw, c)
+		}
+		x, y = p[4], p[5]
+	}
+	if x != 20 || y != 0 {
+		t.Errorf("Arc %s ended at %g,%g", raw, x, y)
+	}
+}
+
+// A smooth curve after a line or an arc has its first control point
+// at the current point.
+func TestExpandSmooth(t *testing.T) {
+	for _, test := range []struct{ raw, want string }{
+		{"M0,0L3,0S6,3,6,6", "M0,0C1,0,2,0,3,0C3,0,6,3,6,6"},
+		{"M0,0A5,5,0,0,1,10,0S13,3,13,6", "C10,0,13,3,13,6"},
+		{"M0,0L3,0T6,6", "M0,0C1,0,2,0,3,0Q3,0,6,6"},
+	} {
+		path, _ := PathParser(test.raw)
+		got := PathFormat{Precision: 3}.Encode(path.Expand())
+		if !strings.HasSuffix(got, test.want) {
+			t.Errorf("Expanded %s to %s, expected it to end %s", test.raw, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package kvg
+
+import "encoding/xml"
+
+// Keeping the attributes and elements which the library doesn't know
+// about, so that files containing them can be read and written back
+// without losing them.
+//
+// The names of the extra attributes and elements are stored with
+// their prefixes in the Local part of the xml.Name, so that they are
+// written back as they were read. See decode.go for how they are read.
+
+// Unmarshaller for an unknown element, which keeps its attributes and
+// its contents as they are.
+func (n *Node) UnmarshalXML(d *xml.Decoder, start xml.StartElement) (err error) {
+	return n.decode(d, start, newDecodeState(Lenient))
+}
+
+// Marshaller for an unknown element, which writes it back as it was
+// read.
+func (n Node) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	start.Name = n.XMLName
+	start.Attr = n.Attrs
+	inner := struct {
+		Inner []byte `xml:",innerxml"`
+	}{n.Inner}
+	return e.EncodeElement(inner, start)
+}
+
+// Unmarshaller for a text.
+func (t *Text) UnmarshalXML(d *xml.Decoder, start xml.StartElement) (err error) {
+	return t.decode(d, start, newDecodeState(Lenient))
+}
+
+// Unmarshaller for the whole file. Since this does not see the
+// DOCTYPE, ParseKanji is better for reading a file.
+func (svg *SVG) UnmarshalXML(d *xml.Decoder, start xml.StartElement) (err error) {
+	return svg.decode(d, start, newDecodeState(Lenient))
+}
@@ -0,0 +1,135 @@
+package kvg
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExtra(t *testing.T) {
+	contents := read(bin() + "/t/08475.svg")
+	// Add some things to the file which the library doesn't know about.
+	edits := []struct{ from, to string }{
+		{`<svg xmlns="http://www.w3.org/2000/svg"`,
+			`<svg xmlns="http://www.w3.org/2000/svg" xmlns:xlink="http://www.w3.org/1999/xlink" data-source="test"`},
+		{`<g id="kvg:08475" kvg:element="葵">`,
+			`<g id="kvg:08475" kvg:element="葵" kvg:comment="two parts" data-checked="yes">
+<desc lang="en">hollyhock <b>aoi</b></desc>`},
+		{`<path id="kvg:08475-s1" kvg:type="㇐"`,
+			`<path id="kvg:08475-s1" data-z="1" kvg:type="㇐" class="first" kvg:weight="2"`},
+		{`<text transform="matrix(1 0 0 1 14.25 23.25)">`,
+			`<text transform="matrix(1 0 0 1 14.25 23.25)" xlink:title="one">`},
+	}
+	for _, e := range edits {
+		if !strings.Contains(contents, e.from) {
+			t.Fatalf("Test file does not contain %s", e.from)
+		}
+		contents = strings.Replace(contents, e.from, e.to, 1)
+	}
+	svg, err := ParseKanji([]byte(contents))
+	if err != nil {
+		t.Fatalf("Error parsing: %s", err)
+	}
+	base := svg.BaseGroup()
+	if len(base.ExtraAttrs) != 2 || base.ExtraAttrs[0].Name.Local != "kvg:comment" {
+		t.Errorf("Wrong extra attributes %v", base.ExtraAttrs)
+	}
+	if len(base.ExtraNodes) != 1 || string(base.ExtraNodes[0].Inner) != "hollyhock <b>aoi</b>" ||
+		base.ExtraNodes[0].After != 0 {
+		t.Errorf("Wrong extra nodes %v", base.ExtraNodes)
+	}
+	if len(base.Children) != 2 {
+		t.Errorf("Extra node was put into children")
+	}
+	s1 := svg.GetPaths()[0]
+	if s1.Class != "first" || len(s1.ExtraAttrs) != 2 {
+		t.Errorf("Path class or attributes lost: %q %v", s1.Class, s1.ExtraAttrs)
+	}
+	output, err := svg.Marshal()
+	if err != nil {
+		t.Fatalf("Error marshalling: %s", err)
+	}
+	out := string(output)
+	for _, want := range []string{
+		`xmlns:xlink="http://www.w3.org/1999/xlink" data-source="test" width="109"`,
+		`kvg:element="葵" kvg:comment="two parts" data-checked="yes">`,
+		`<desc lang="en">hollyhock <b>aoi</b></desc>`,
+		`<path id="kvg:08475-s1" data-z="1" kvg:type="㇐" class="first" kvg:weight="2" d="M20.5,`,
+		`xlink:title="one">1</text>`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Output does not contain %s", want)
+		}
+	}
+	again, err := ParseKanji(output)
+	if err != nil {
+		t.Fatalf("Error parsing output: %s", err)
+	}
+	output2, err := again.Marshal()
+	if err != nil {
+		t.Fatalf("Error marshalling: %s", err)
+	}
+	if string(output2) != out {
+		t.Errorf("Second round trip changed the output")
+	}
+}
+
+// Unknown elements and attributes stay where they were.
+func TestExtraOrder(t *testing.T) {
+	contents := read(bin() + "/t/08475.svg")
+	edits := []struct{ from, to string }{
+		{"\t\t<path id=\"kvg:08475-s2\"",
+			"\t\t<desc>between s1 and s2</desc>\n\t\t<path data-first=\"yes\" id=\"kvg:08475-s2\""},
+		{"<g id=\"kvg:StrokeNumbers_08475\"",
+			"<metadata>numbers</metadata>\n<g id=\"kvg:StrokeNumbers_08475\""},
+	}
+	for _, e := range edits {
+		if !strings.Contains(contents, e.from) {
+			t.Fatalf("Test file does not contain %s", e.from)
+		}
+		contents = strings.Replace(contents, e.from, e.to, 1)
+	}
+	svg, err := ParseKanji([]byte(contents))
+	if err != nil {
+		t.Fatalf("Error parsing: %s", err)
+	}
+	output, err := svg.Marshal()
+	if err != nil {
+		t.Fatalf("Error marshalling: %s", err)
+	}
+	if string(output) != contents {
+		t.Errorf("Round trip changed the file:\n%s", output)
+	}
+	// The unknown element stays between the same children through
+	// edits, and is not one of the children.
+	g := svg.GetPaths()[0].Parent()
+	if len(g.Children) != 3 || len(g.ExtraNodes) != 1 {
+		t.Fatalf("Unknown element was put into children")
+	}
+	err = svg.MoveChild(g, 0, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	output, _ = svg.Marshal()
+	if !strings.Contains(string(output), "<desc>between s1 and s2</desc>\n\t\t<path data-first=\"yes\" id=\"kvg:08475-s1\"") {
+		t.Errorf("Unknown element moved by MoveChild:\n%s", output)
+	}
+	err = svg.MoveChild(g, 2, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wrapper, err := svg.WrapInGroup(g, 0, 2, Group{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(wrapper.ExtraNodes) != 1 || len(g.ExtraNodes) != 0 {
+		t.Errorf("Unknown element not moved into the new group")
+	}
+	err = svg.Unwrap(wrapper)
+	if err != nil {
+		t.Fatal(err)
+	}
+	output, _ = svg.Marshal()
+	if string(output) != contents {
+		t.Errorf("Edits changed the file:\n%s", output)
+	}
+}
@@ -2,74 +2,117 @@
 package kvg
 
 import (
+	"bytes"
 	"encoding/xml"
+	"errors"
 	"fmt"
 	"io/fs"
 	"log"
 	"os"
-	"path/filepath"
 	"regexp"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"unicode"
 )
 
 // This matches most of the variant endings.
 var Variant = regexp.MustCompile(`-(Kaisho|MidFst|HzLst|VtLst|HzFstLeRi|HzFstRiLe|TenLst|Hyougai|Jinmei|HzFst|VtFstRiLe|LeFst|Vt6|VtFstRiLe|HzFst|HzFstVtLst|MdLst|VtFst|Vt4|Ten3|DgLst|Insatsu|MdFst|MdFst2|Dg3|TenFst|RiLe|NoDot)`)
 
-// The base directory
+// The base directory used by ExamineAllFiles, ExamineAllFilesSimple
+// and TFile.
+//
+// Deprecated: Use a Corpus, which can be opened from a directory, an
+// fs.FS or the KANJIVG_DIR environment variable.
 var KVDir = "/home/ben/software/kanjivg/kanji"
 
+// The corpus in KVDir.
+func kvDirCorpus() *Corpus {
+	return &Corpus{Dir: KVDir, FS: os.DirFS(KVDir)}
+}
+
 // A path, in other words a stroke of the kanji.
 type Path struct {
 	XMLName xml.Name `xml:"path"`
 	ID      string   `xml:"id,attr"`
 	Type    string   `xml:"kvg:type,attr,omitempty"`
 	D       string   `xml:"d,attr"`
+	Class   string   `xml:"class,attr,omitempty"`
+	// Attributes which are not one of the above, in the order they
+	// were read. See Node for the form of the names.
+	ExtraAttrs []xml.Attr `xml:",any,attr"`
 	// The node in the tree of either groups or paths which
-	// corresponds to this path.
-	Parent *Child `xml:"-"`
-	Class  string `xml:"class,attr,omitempty"`
+	// corresponds to this path. Use the Parent method to get the
+	// group containing the path.
+	node *Child
+	// The names of the attributes in the order they were read.
+	attrOrder []string
+	// The position of the path before an edit, see tagPaths.
+	seq int
+	// The bounding box of the path as a pathBox, see BoundingBox.
+	box atomic.Value
 }
 
-// Text holder, this contains the stroke numbers.
-type Text struct {
-	XMLName   xml.Name `xml:"text"`
-	Transform string   `xml:"transform,attr,omitempty"`
-	Content   []byte   `xml:",chardata"`
-	Parent    *Child   `xml:"-"`
-	Class     string   `xml:"class,attr,omitempty"`
+// The bounding box of a path, and the value of D it was computed from.
+type pathBox struct {
+	d string
+	r Rect
 }
 
-// Either a group or a path element.
+// Text holder, this contains the stroke numbers.
+type Text struct {
+	XMLName   xml.Name  `xml:"text"`
+	Transform Transform `xml:"transform,attr,omitempty"`
+	Content   []byte    `xml:",chardata"`
+	Class     string    `xml:"class,attr,omitempty"`
+	// Attributes which are not one of the above.
+	ExtraAttrs []xml.Attr `xml:",any,attr"`
+	// The names of the attributes in the order they were read.
+	attrOrder []string
+	// The child in the tree which holds this text.
+	node *Child
+}
+
+// Either a group, a path or a text element.
 type Child struct {
 	Path    Path
 	Group   Group
 	Text    Text
 	IsGroup bool
 	IsText  bool
-	// The parent of this element in the tree of groups.
-	Parent *Group `xml:"-"`
+	// The parent of this element in the tree of groups. Use the
+	// Parent method to get this.
+	parent *Group
 }
 
 // A group.
 type Group struct {
-	XMLName     xml.Name `xml:"g"`
-	ID          string   `xml:"id,attr,omitempty"`
-	Element     string   `xml:"kvg:element,attr,omitempty"`
-	Part        string   `xml:"kvg:part,attr,omitempty"`
-	Variant     bool     `xml:"kvg:variant,attr,omitempty"`
-	Number      string   `xml:"kvg:number,attr,omitempty"`
-	Original    string   `xml:"kvg:original,attr,omitempty"`
-	Partial     bool     `xml:"kvg:partial,attr,omitempty"`
-	TradForm    string   `xml:"kvg:tradForm,attr,omitempty"`
-	Position    string   `xml:"kvg:position,attr,omitempty"`
-	Radical     string   `xml:"kvg:radical,attr,omitempty"`
-	Phon        string   `xml:"kvg:phon,attr,omitempty"`
-	RadicalForm string   `xml:"kvg:radicalForm,attr,omitempty"`
-	Style       string   `xml:"style,attr,omitempty"`
-	Children    []Child
-	Parent      *Child `xml:"-"`
+	XMLName     xml.Name    `xml:"g"`
+	ID          string      `xml:"id,attr,omitempty"`
+	Element     string      `xml:"kvg:element,attr,omitempty"`
+	Part        Part        `xml:"kvg:part,attr,omitempty"`
+	Variant     bool        `xml:"kvg:variant,attr,omitempty"`
+	Number      string      `xml:"kvg:number,attr,omitempty"`
+	Original    string      `xml:"kvg:original,attr,omitempty"`
+	Partial     bool        `xml:"kvg:partial,attr,omitempty"`
+	TradForm    string      `xml:"kvg:tradForm,attr,omitempty"`
+	Position    Position    `xml:"kvg:position,attr,omitempty"`
+	Radical     RadicalType `xml:"kvg:radical,attr,omitempty"`
+	Phon        string      `xml:"kvg:phon,attr,omitempty"`
+	RadicalForm string      `xml:"kvg:radicalForm,attr,omitempty"`
+	Style       string      `xml:"style,attr,omitempty"`
+	// Attributes which are not one of the above, in the order they
+	// were read.
+	ExtraAttrs []xml.Attr `xml:",any,attr"`
+	Children   []Child
+	// Elements other than g, path and text, in the order they were
+	// read. Each is written before the child given by its After.
+	ExtraNodes []Node `xml:",any"`
+	// The names of the attributes in the order they were read.
+	attrOrder []string
+	// The child in the tree which holds this group, or nil for the
+	// top-level groups of the SVG.
+	node *Child
 }
 
 // An entire file.
@@ -79,7 +122,29 @@ type SVG struct {
 	Width   string   `xml:"width,attr"`
 	Height  string   `xml:"height,attr"`
 	ViewBox string   `xml:"viewBox,attr,omitempty"`
-	Groups  []Group  `xml:"g"`
+	// Attributes which are not one of the above.
+	ExtraAttrs []xml.Attr `xml:",any,attr"`
+	Groups     []Group    `xml:"g"`
+	// Elements other than g, in the order they were read. Each is
+	// written before the group given by its position in Groups.
+	ExtraNodes []Node `xml:",any"`
+	// The names of the attributes in the order they were read.
+	attrOrder []string
+}
+
+// An element which the kvg library does not know about, such as a
+// desc or metadata element, kept so that it can be written back
+// unchanged. The names of the element and its attributes are stored
+// with their prefixes in Local, for example "kvg:comment", and the
+// contents are the original XML text.
+type Node struct {
+	XMLName xml.Name
+	Attrs   []xml.Attr
+	Inner   []byte
+	// The number of children of the group, or groups of the SVG,
+	// which the node comes after. A node whose After is past the end
+	// is written last.
+	After int
 }
 
 // This is the heading as repeated in each file.
@@ -136,50 +201,72 @@ func ExpectRadical(k rune) bool {
 	return true
 }
 
-// Change the formatting of xmlout to that used by KanjiVG project,
-// and add the common heading material. The KanjiVG files are indented
-// using tabs, but with some of the indentation inconsistent.
-func fixXML(xmlout []byte) []byte {
-	outFixed := strings.Replace(string(xmlout), "></path>", "/>", -1)
-	outFixed = strings.Replace(outFixed, "\t<g", "<g", -1)
-	outFixed = strings.Replace(outFixed, "\t<g", "<g", -1)
-	outFixed = strings.Replace(outFixed, "\t\t<path", "<path", -1)
-	outFixed = strings.Replace(outFixed, "\t<text", "<text", -1)
-	outFixed = strings.Replace(outFixed, "\t</g>", "</g>", -1)
-	outFixed = strings.Replace(outFixed, "\t</g>", "</g>", -1)
-	outstring := Heading + outFixed + "\n"
-	return []byte(outstring)
-}
-
 // Make kanjivg into the XML of the KanjiVG files.
 func (kanjivg *SVG) MakeXML() (output []byte) {
 	return MakeXML(kanjivg)
 }
 
-// Make kanjivg into the XML of the KanjiVG files.
+// Make kanjivg into the XML of the KanjiVG files. This exits the
+// program if there is an error, see MarshalKanji for a version which
+// returns the error.
 func MakeXML(kanjivg *SVG) (output []byte) {
-	kanjivg.RenumberXML()
-	output, err := xml.MarshalIndent(*kanjivg, "", "\t")
+	output, err := MarshalKanji(kanjivg)
 	if err != nil {
 		log.Fatalf("Error marshalling: %s\n", err)
 	}
-	output = fixXML(output)
 	return output
 }
 
+// See the documentation for MarshalKanji(kanjivg).
+func (kanjivg *SVG) Marshal() (output []byte, err error) {
+	return MarshalKanji(kanjivg)
+}
+
+// Renumber kanjivg and make it into the XML of the KanjiVG files. The
+// error is either ErrNoBaseGroup or ErrMarshal.
+func MarshalKanji(kanjivg *SVG) (output []byte, err error) {
+	err = kanjivg.renumberXML()
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	buf.WriteString(Heading)
+	err = NewEncoder(&buf).Encode(kanjivg)
+	if err != nil {
+		return nil, &Error{Op: "MarshalKanji", Kind: ErrMarshal, Err: err}
+	}
+	return buf.Bytes(), nil
+}
+
 // Write kanjivg out as a file.
 func (kanjivg *SVG) WriteKanjiFile(file string) {
 	WriteKanjiFile(file, kanjivg)
 }
 
-// Write kanjivg to file.
+// Write kanjivg to file. This exits the program if there is an
+// error, see SaveKanjiFile for a version which returns the error.
 func WriteKanjiFile(file string, kanjivg *SVG) {
-	err := os.WriteFile(file, MakeXML(kanjivg), 0644)
+	err := SaveKanjiFile(file, kanjivg)
 	if err != nil {
 		log.Fatalf("Error writing %s: %s\n", file, err)
 	}
 }
 
+// See the documentation for SaveKanjiFile(file, kanjivg).
+func (kanjivg *SVG) Save(file string) (err error) {
+	return SaveKanjiFile(file, kanjivg)
+}
+
+// Write kanjivg to file, returning any error from marshalling or
+// writing the file.
+func SaveKanjiFile(file string, kanjivg *SVG) (err error) {
+	output, err := MarshalKanji(kanjivg)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(file, output, 0644)
+}
+
 // Special marshaller for "child" elements, since a g may contain
 // either a path or a text or another g element.
 func (c Child) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
@@ -197,137 +284,44 @@ func (c Child) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
 
 // Special unmarshaller. For some reason the kvg:type parts were not
 // being picked up by the default parser, so I wrote this in order to
-// work around that. There must be something I have missed about how
-// the default unmarshal routine works.
+// work around that. The reason is that the kvg prefix is declared in
+// the DOCTYPE, which the XML decoder does not read. See decode.go for
+// how the namespaces are handled.
 func (p *Path) UnmarshalXML(d *xml.Decoder, start xml.StartElement) (err error) {
-	for _, attr := range start.Attr {
-		switch attr.Name.Local {
-		case "d":
-			p.D = attr.Value
-		case "id":
-			p.ID = attr.Value
-		case "type":
-			p.Type = attr.Value
-		}
-	}
-	for {
-		/* There is no content in the paths. */
-		token, err := d.Token()
-		if err != nil {
-			return err
-		}
-		switch el := token.(type) {
-		case xml.EndElement:
-			if el == start.End() {
-				return nil
-			}
-		}
-	}
+	return p.decode(d, start, newDecodeState(Lenient))
 }
 
 // Unmarshaller for a group.
 func (g *Group) UnmarshalXML(d *xml.Decoder, start xml.StartElement) (err error) {
-	for _, attr := range start.Attr {
-		switch attr.Name.Local {
-		case "element":
-			g.Element = attr.Value
-		case "id":
-			g.ID = attr.Value
-		case "number":
-			g.Number = attr.Value
-		case "original":
-			g.Original = attr.Value
-		case "part":
-			g.Part = attr.Value
-		case "partial":
-			if attr.Value == "true" {
-				g.Partial = true
-			}
-		case "phon":
-			g.Phon = attr.Value
-		case "position":
-			g.Position = attr.Value
-		case "radical":
-			g.Radical = attr.Value
-		case "radicalForm":
-			g.RadicalForm = attr.Value
-		case "style":
-			g.Style = attr.Value
-		case "tradForm":
-			g.TradForm = attr.Value
-		case "variant":
-			if attr.Value == "true" {
-				g.Variant = true
-			}
-		default:
-			fmt.Printf("Unhandled -> %s\n", attr.Name.Local)
-		}
-	}
-	for {
-		token, err := d.Token()
-		if err != nil {
-			return err
-		}
-		var c Child
-		c.Parent = g
-		fail := false
-		switch el := token.(type) {
-		case xml.StartElement:
-			switch el.Name.Local {
-			case "g":
-				err = d.DecodeElement(&c.Group, &el)
-				if err != nil {
-					return err
-				}
-				c.IsGroup = true
-				c.IsText = false
-				c.Group.Parent = &c
-			case "path":
-				err = d.DecodeElement(&c.Path, &el)
-				if err != nil {
-					return err
-				}
-				c.IsGroup = false
-				c.IsText = false
-				c.Path.Parent = &c
-			case "text":
-				err = d.DecodeElement(&c.Text, &el)
-				if err != nil {
-					return err
-				}
-				c.IsText = true
-				c.IsGroup = false
-				c.Text.Parent = &c
-			default:
-				fmt.Printf("Unhandled -> %s\n", el.Name.Local)
-				fail = true
-			}
-			if !fail {
-				g.Children = append(g.Children, c)
-			}
-		case xml.EndElement:
-			if el == start.End() {
-				return nil
-			}
-		}
-	}
+	return g.decode(d, start, newDecodeState(Lenient))
 }
 
 // Given a kanji in "contents", parse it into kanjivg. The error value
-// comes from the XML unmarshalling.
+// comes from the XML unmarshalling. This uses the Lenient namespace
+// mode, see ParseKanjiMode.
 func ParseKanji(contents []byte) (kanjivg SVG, oerr error) {
-	oerr = xml.Unmarshal(contents, &kanjivg)
-	if oerr != nil {
-		return kanjivg, oerr
-	}
-	return kanjivg, nil
+	return ParseKanjiMode(contents, Lenient)
 }
 
 // The "parent" or "base" group of an SVG. This is a pointer to a
 // value within kvg itself. See also Grab for an easy function which
-// gets both the SVG and the base group from a file.
+// gets both the SVG and the base group from a file. This exits the
+// program if there is no base group, see FindBaseGroup for a version
+// which returns an error.
 func (kvg *SVG) BaseGroup() (group *Group) {
-	return &kvg.Groups[0].Children[0].Group
+	group, err := kvg.FindBaseGroup()
+	die(err, "Error getting base group")
+	return group
+}
+
+// Get the "parent" or "base" group of an SVG, or ErrNoBaseGroup if
+// the SVG does not have one.
+func (kvg *SVG) FindBaseGroup() (group *Group, err error) {
+	if len(kvg.Groups) == 0 || len(kvg.Groups[0].Children) == 0 ||
+		!kvg.Groups[0].Children[0].IsGroup {
+		return nil, &Error{Op: "FindBaseGroup", Kind: ErrNoBaseGroup}
+	}
+	return &kvg.Groups[0].Children[0].Group, nil
 }
 
 // Given a kanji file, read it and put the contents into kanjivg.
@@ -353,6 +347,9 @@ func renumber(child *Child, base string, nPathPtr, nGroupPtr *int64) {
 		}
 		return
 	}
+	if !child.IsPath() {
+		return
+	}
 	*nPathPtr++
 	(*child).Path.ID = fmt.Sprintf("%s-s%d", base, *nPathPtr)
 }
@@ -374,10 +371,24 @@ func (kvg *SVG) Base() (base, baseNoKVG string) {
 // output will use this base value, so there is no need to set it for
 // each element.
 func (kvg *SVG) SetBase(base string) {
-	if base[0:4] != "kvg:" {
-		log.Fatalf("Base name '%s' does not start with 'kvg:'", base)
+	err := kvg.ChangeBase(base)
+	if err != nil {
+		log.Fatalf("Error setting base name '%s': %s", base, err)
+	}
+}
+
+// Change the base of kvg as for SetBase, but return an error rather
+// than exiting. The error is ErrBadID if base does not start with
+// "kvg:", or ErrNoBaseGroup.
+func (kvg *SVG) ChangeBase(base string) (err error) {
+	if !strings.HasPrefix(base, "kvg:") {
+		return &Error{Op: "ChangeBase", Name: base, Kind: ErrBadID,
+			Err: errors.New("base name does not start with 'kvg:'")}
+	}
+	baseGroup, err := kvg.FindBaseGroup()
+	if err != nil {
+		return err
 	}
-	baseGroup := kvg.BaseGroup()
 	baseGroup.ID = base
 	tail := base[4:]
 	kvg.Groups[0].ID = "kvg:StrokePaths_" + tail
@@ -391,6 +402,8 @@ func (kvg *SVG) SetBase(base string) {
 	for i := range baseGroup.Children {
 		renumber(&baseGroup.Children[i], base, &nPath, &nGroup)
 	}
+	kvg.Link()
+	return nil
 }
 
 // Renumber the labels of the "text" group. The numerical labels given
@@ -398,6 +411,9 @@ func (kvg *SVG) SetBase(base string) {
 // group, so the user does not need to keep track of the original
 // numbers within the file.
 func (kvg *SVG) RenumberLabels() {
+	if len(kvg.Groups) < 2 {
+		return
+	}
 	labels := kvg.Groups[1]
 	for i := range labels.Children {
 		c := &labels.Children[i]
@@ -405,28 +421,44 @@ func (kvg *SVG) RenumberLabels() {
 			log.Printf("Error: non-text child in label %d\n", i+1)
 			continue
 		}
-		c.Text.Content = []byte(fmt.Sprintf("%d", i+1))
+		c.Text.SetNumber(i + 1)
 	}
 }
 
 // Renumber an XML file read into "kvg" and also check its "style"
 // elements.
 func (svg *SVG) RenumberXML() {
+	err := svg.renumberXML()
+	die(err, "Error renumbering")
+}
+
+// Error-returning version of RenumberXML.
+func (svg *SVG) renumberXML() (err error) {
 	var nPath int64
 	var nGroup int64
-	baseGroup := svg.BaseGroup()
+	baseGroup, err := svg.FindBaseGroup()
+	if err != nil {
+		return err
+	}
 	base := baseGroup.ID
 	for i := range baseGroup.Children {
 		renumber(&baseGroup.Children[i], base, &nPath, &nGroup)
 	}
 	svg.RenumberLabels()
 	svg.SetStyle()
+	svg.Link()
+	return nil
 }
 
 // Set the "style" element to the normal KVG one.
 func (svg *SVG) SetStyle() {
+	if len(svg.Groups) == 0 {
+		return
+	}
 	svg.Groups[0].Style = "fill:none;stroke:#000000;stroke-width:3;stroke-linecap:round;stroke-linejoin:round;"
-	svg.Groups[1].Style = "font-size:8;fill:#808080"
+	if len(svg.Groups) > 1 {
+		svg.Groups[1].Style = "font-size:8;fill:#808080"
+	}
 	removeChildStyles(&svg.Groups[0])
 }
 
@@ -440,11 +472,22 @@ func removeChildStyles(g *Group) {
 	}
 }
 
-// Read, renumber, and then write out a kanji file.
+// Read, renumber, and then write out a kanji file. This exits the
+// program if there is an error, see RenumberKanjiFile for a version
+// which returns the error.
 func RenumberFile(file string) {
-	kvg := ReadKanjiFileOrDie(file)
-	kvg.RenumberXML()
-	WriteKanjiFile(file, &kvg)
+	err := RenumberKanjiFile(file)
+	die(err, "Error renumbering '%s'", file)
+}
+
+// Read, renumber, and then write out a kanji file, returning any
+// error.
+func RenumberKanjiFile(file string) (err error) {
+	kvg, err := ReadKanjiFile(file)
+	if err != nil {
+		return err
+	}
+	return SaveKanjiFile(file, &kvg)
 }
 
 // Helper for FindMultiElement
@@ -508,7 +551,25 @@ func (gp *Group) FindElement(funky string) (found bool, loc []*Group) {
 // on. If the element is not found, return value is false and an empty
 // slice.
 func FindElement(gp *Group, funky string) (found bool, loc []*Group) {
-	if gp.Element == funky {
+	return FindElementNorm(gp, funky, nil)
+}
+
+// See the documentation for FindElementNorm(group,funky,norm)
+func (gp *Group) FindElementNorm(funky string, norm ElementNormalizer) (found bool, loc []*Group) {
+	return FindElementNorm(gp, funky, norm)
+}
+
+// Find a group with the element "funky" like FindElement, but compare
+// the elements after applying norm to them. For example, with
+// CanonicalElement as norm, searching for 氵 also finds ⺡.
+func FindElementNorm(gp *Group, funky string, norm ElementNormalizer) (found bool, loc []*Group) {
+	return findElement(gp, norm.apply(funky), norm)
+}
+
+// Recursive helper for FindElementNorm, where funky has already been
+// normalized.
+func findElement(gp *Group, funky string, norm ElementNormalizer) (found bool, loc []*Group) {
+	if norm.apply(gp.Element) == funky {
 		found = true
 		loc = []*Group{gp}
 		return true, loc
@@ -516,7 +577,7 @@ func FindElement(gp *Group, funky string) (found bool, loc []*Group) {
 	for i, g := range gp.Children {
 		if g.IsGroup {
 			gPtr := &gp.Children[i].Group
-			found, loc = FindElement(gPtr, funky)
+			found, loc = findElement(gPtr, funky, norm)
 			if found {
 				loc = append(loc, gp)
 				return true, loc
@@ -547,14 +608,18 @@ func getPaths(g *Group) (paths []*Path) {
 			paths = append(paths, gpaths...)
 			continue
 		}
-		paths = append(paths, &c.Path)
+		if c.IsPath() {
+			paths = append(paths, &c.Path)
+		}
 	}
 	return paths
 }
 
 // Remove the KVDir prefix from a file name.
+//
+// Deprecated: Use Corpus.Rel.
 func TFile(file string) string {
-	return strings.TrimPrefix(file, KVDir+"/")
+	return kvDirCorpus().Rel(file)
 }
 
 // The structure which contains the radical information for a kanji. A
@@ -587,25 +652,25 @@ func (g *Group) SearchRadical(radPtr *Radical) {
 		return
 	}
 	switch rad {
-	case "general":
+	case RadicalGeneral:
 		if PrintDouble && len(radPtr.General) > 0 {
 			fmt.Printf("Double %s for General.\n",
 				radPtr.General[0].ID)
 		}
 		(*radPtr).General = append((*radPtr).General, g)
-	case "nelson":
+	case RadicalNelson:
 		if PrintDouble && len(radPtr.Nelson) > 0 {
 			fmt.Printf("Double %s for Nelson.\n",
 				radPtr.Nelson[0].ID)
 		}
 		(*radPtr).Nelson = append((*radPtr).Nelson, g)
-	case "jis":
+	case RadicalJIS:
 		if PrintDouble && len(radPtr.JIS) > 0 {
 			fmt.Printf("Double %s for nelson.\n",
 				radPtr.JIS[0].ID)
 		}
 		(*radPtr).JIS = append((*radPtr).JIS, g)
-	case "tradit":
+	case RadicalTradit:
 		if PrintDouble && len(radPtr.Tradit) > 0 {
 			fmt.Printf("Double %s for tradit.\n",
 				radPtr.Tradit[0].ID)
@@ -623,22 +688,49 @@ var fileIDRe = regexp.MustCompile(".*" + hexID + "(?:-.+)?\\.svg$")
 var filePartRe = regexp.MustCompile("(?:.*/)?(" + hexID + "(?:-([A-Za-z][A-Za-z0-9]*))?)\\.svg$")
 
 // Hexadecimal to number. We already know hexID is valid from the
-// regex validation, so we can fail fatally if this fails.
+// regex validation, so we can fail fatally if this fails. See
+// ParseHexID for a version which returns an error.
 func HexIDToNum(hexID string) (num int64) {
-	num, err := strconv.ParseInt(hexID, 16, 64)
+	num, err := ParseHexID(hexID)
 	die(err, "Error parsing hex number")
 	return num
 }
 
+// Hexadecimal to number. The error is ErrBadID.
+func ParseHexID(hexID string) (num int64, err error) {
+	num, err = strconv.ParseInt(hexID, 16, 64)
+	if err != nil {
+		return 0, &Error{Op: "ParseHexID", Name: hexID, Kind: ErrBadID, Err: err}
+	}
+	return num, nil
+}
+
 // Given a KanjiVG file name fileName, return the hexadecimal id
-// number, the kanji as a number, and the extension.
+// number, the kanji as a number, and the extension. If fileName is not
+// a KanjiVG file name, all three are empty. See ParseFileParts for a
+// version which returns an error.
 func FileToParts(fileName string) (id string, num int64, extension string) {
+	id, num, extension, err := ParseFileParts(fileName)
+	if errors.Is(err, ErrBadFileName) {
+		return "", 0, ""
+	}
+	die(err, "Error parsing %s", fileName)
+	return id, num, extension
+}
+
+// Given a KanjiVG file name fileName, return the hexadecimal id
+// number, the kanji as a number, and the extension. The error is
+// ErrBadFileName or ErrBadID.
+func ParseFileParts(fileName string) (id string, num int64, extension string, err error) {
 	match := filePartRe.FindStringSubmatch(fileName)
 	if len(match) == 0 {
-		return "", 0, ""
+		return "", 0, "", &Error{Op: "ParseFileParts", Name: fileName, Kind: ErrBadFileName}
 	}
-	num = HexIDToNum(match[2])
-	return match[1], num, match[3]
+	num, err = ParseHexID(match[2])
+	if err != nil {
+		return "", 0, "", err
+	}
+	return match[1], num, match[3], nil
 }
 
 var Backup = regexp.MustCompile(`/\.#|/#|~$`)
@@ -662,13 +754,20 @@ func (base *Group) GetGroups() (groups []*Group) {
 // element to the group. See GetGroups for a simpler list return
 // function.
 func (base *Group) Subgroups() (elgr map[string][]*Group) {
-	el := base.Element
+	return base.SubgroupsNorm(nil)
+}
+
+// Like Subgroups, but the keys of the map are the elements after
+// applying norm to them, so that for example with CanonicalElement,
+// the groups with elements ⺡ and 氵 are both under 氵.
+func (base *Group) SubgroupsNorm(norm ElementNormalizer) (elgr map[string][]*Group) {
+	el := norm.apply(base.Element)
 	elgr = make(map[string][]*Group)
 	for i, c := range base.Children {
 		if !c.IsGroup {
 			continue
 		}
-		celgr := base.Children[i].Group.Subgroups()
+		celgr := base.Children[i].Group.SubgroupsNorm(norm)
 		for s, gr := range celgr {
 			elgr[s] = append(elgr[s], gr...)
 		}
@@ -701,49 +800,41 @@ func ExamineKanjiFile(path string, d fs.DirEntry, fn SVGFunc) (oerr error) {
 	return nil
 }
 
-// Examine all the files in KVDir. Before using this, set KVDir to the
-// value on your system. It will go through each file, read its
-// contents, and call the function fn you specify on each of them. See
-// also ExamineAllFilesSimple, which doesn't read the file contents
-// first. This is usually better if you want to just check some files,
-// since you can check the Unicode ID of the character using
-// FileToNum, and decide whether to read it all in, rather than
-// reading everything for all files.
+// Examine all the files in KVDir, exiting the program if there is an
+// error.
+//
+// Deprecated: Use Corpus.ExamineAllFiles.
 func ExamineAllFiles(fn SVGFileFunc) {
-	filepath.WalkDir(KVDir, func(path string, d fs.DirEntry, err error) error {
-		if d.IsDir() {
-			return nil
-		}
-		kanjivg := ReadKanjiFileOrDie(path)
-		fn(path, kanjivg)
-		return nil
-	})
+	err := kvDirCorpus().ExamineAllFiles(fn)
+	die(err, "Error examining %s", KVDir)
 }
 
-// Examine all the files in KVDir. Before using this, set KVDir to the
-// value on your system. It will go through each file and call fn on
-// them. It doesn't read the contents, unlike ExamineAllFiles.
+// Examine all the files in KVDir without reading them, exiting the
+// program if there is an error.
+//
+// Deprecated: Use Corpus.ExamineAllFilesSimple.
 func ExamineAllFilesSimple(fn func(file string)) {
-	filepath.WalkDir(KVDir, func(path string, d fs.DirEntry, err error) error {
-		if d.IsDir() {
-			return nil
-		}
-		if Backup.MatchString(path) {
-			return nil
-		}
-		fn(path)
-		return nil
-	})
+	err := kvDirCorpus().ExamineAllFilesSimple(fn)
+	die(err, "Error examining %s", KVDir)
 }
 
-// Get just the Unicode number from a kanjivg file name.
+// Get just the Unicode number from a kanjivg file name. This exits
+// the program if fileName is not a KanjiVG file name, see
+// ParseFileNum for a version which returns an error.
 func FileToNum(fileName string) (num int64) {
+	num, err := ParseFileNum(fileName)
+	die(err, "No match in %s", fileName)
+	return num
+}
+
+// Get just the Unicode number from a kanjivg file name. The error is
+// ErrBadFileName.
+func ParseFileNum(fileName string) (num int64, err error) {
 	match := fileIDRe.FindStringSubmatch(fileName)
 	if len(match) == 0 {
-		fmt.Printf("No match in %s\n", fileName)
-		os.Exit(1)
+		return 0, &Error{Op: "ParseFileNum", Name: fileName, Kind: ErrBadFileName}
 	}
-	return HexIDToNum(match[1])
+	return ParseHexID(match[1])
 }
 
 // Die if there is an error otherwise do nothing.
@@ -774,9 +865,22 @@ func ReadKanjiFileOrDie(fileName string) (svg SVG) {
 // out again, one wants the full SVG, so this is a handy function in
 // practice.
 func Grab(fileName string) (svgPtr *SVG, base *Group) {
-	svg := ReadKanjiFileOrDie(fileName)
-	base = svg.BaseGroup()
-	return &svg, base
+	svgPtr, base, err := GrabFile(fileName)
+	die(err, "Error reading '%s'", fileName)
+	return svgPtr, base
+}
+
+// Error-returning version of Grab.
+func GrabFile(fileName string) (svgPtr *SVG, base *Group, err error) {
+	svg, err := ReadKanjiFile(fileName)
+	if err != nil {
+		return nil, nil, err
+	}
+	base, err = svg.FindBaseGroup()
+	if err != nil {
+		return nil, nil, err
+	}
+	return &svg, base, nil
 }
 
 // Given a group g, try to find an element with type (stroke shape)
@@ -795,7 +899,7 @@ func FindType(g *Group, t string) (found bool, loc []*Child) {
 			continue
 		}
 		gc := &g.Children[i]
-		if gc.Path.Type == t {
+		if gc.IsPath() && gc.Path.Type == t {
 			return true, []*Child{gc}
 		}
 	}
@@ -819,6 +923,9 @@ func (g *Group) dump(depth int) (s string) {
 			s += c.Group.dump(depth + 1)
 			continue
 		}
+		if !c.IsPath() {
+			continue
+		}
 		s += fmt.Sprintf("%s  %s %s\n", indent, c.Path.ID, c.Path.Type)
 	}
 	return s
@@ -836,18 +943,27 @@ func (svg *SVG) GetPaths() (paths []*Path) {
 	return getPaths(base)
 }
 
-// Get the numeric part of a path ID.
+// Get the numeric part of a path ID. This exits the program if id is
+// not a path ID, see ParsePathID for a version which returns an
+// error.
 func PathIDToNum(id string) (num int64) {
-	match := pathIDRe.FindStringSubmatch(id)
-	return decimalToNum(match[2])
+	num, err := ParsePathID(id)
+	die(err, "Error parsing path ID")
+	return num
 }
 
-// Decimal to number. We already know it is valid from the regex
-// validation so we can fail fatally if this fails.
-func decimalToNum(Decimal string) (num int64) {
-	num, err := strconv.ParseInt(Decimal, 10, 64)
-	die(err, "Error parsing decimal")
-	return num
+// Get the numeric part of a path ID such as "kvg:08475-s12". The
+// error is ErrBadID.
+func ParsePathID(id string) (num int64, err error) {
+	match := pathIDRe.FindStringSubmatch(id)
+	if len(match) == 0 {
+		return 0, &Error{Op: "ParsePathID", Name: id, Kind: ErrBadID}
+	}
+	num, err = strconv.ParseInt(match[2], 10, 64)
+	if err != nil {
+		return 0, &Error{Op: "ParsePathID", Name: id, Kind: ErrBadID, Err: err}
+	}
+	return num, nil
 }
 
 // Given a group g, return its element
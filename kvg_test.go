@@ -1,10 +1,15 @@
 package kvg
 
 import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
 	"runtime"
 	"testing"
+	"testing/fstest"
 )
 
 func bin() string {
@@ -39,3 +44,130 @@ func TestWriteKanjiFile(t *testing.T) {
 	err = os.Remove(ofile)
 	die(err, "Error removing %s", ofile)
 }
+
+func TestErrors(t *testing.T) {
+	_, err := ParseFileNum("monkey.svg")
+	if !errors.Is(err, ErrBadFileName) {
+		t.Errorf("ParseFileNum: expected ErrBadFileName, got %v", err)
+	}
+	num, err := ParseFileNum("/some/where/08475-Kaisho.svg")
+	if err != nil || num != 0x8475 {
+		t.Errorf("ParseFileNum: got %x, %v", num, err)
+	}
+	id, num, ext, err := ParseFileParts("/some/where/08475-Kaisho.svg")
+	if err != nil || id != "08475-Kaisho" || num != 0x8475 || ext != "Kaisho" {
+		t.Errorf("ParseFileParts: got %s, %x, %s, %v", id, num, ext, err)
+	}
+	_, _, _, err = ParseFileParts("monkey.svg")
+	if !errors.Is(err, ErrBadFileName) {
+		t.Errorf("ParseFileParts: expected ErrBadFileName, got %v", err)
+	}
+	if id, _, _ := FileToParts("monkey.svg"); id != "" {
+		t.Errorf("FileToParts: got %s for a bad name", id)
+	}
+	_, err = ParseHexID("zzzzz")
+	if !errors.Is(err, ErrBadID) {
+		t.Errorf("ParseHexID: expected ErrBadID, got %v", err)
+	}
+	num, err = ParsePathID("kvg:08475-s12")
+	if err != nil || num != 12 {
+		t.Errorf("ParsePathID: got %d, %v", num, err)
+	}
+	_, err = ParsePathID("kvg:08475-g3")
+	if !errors.Is(err, ErrBadID) {
+		t.Errorf("ParsePathID: expected ErrBadID, got %v", err)
+	}
+	var svg SVG
+	_, err = svg.Marshal()
+	if !errors.Is(err, ErrNoBaseGroup) {
+		t.Errorf("Marshal: expected ErrNoBaseGroup, got %v", err)
+	}
+	svg, err = ReadKanjiFile(bin() + "/t/08475.svg")
+	if err != nil {
+		t.Fatalf("Error reading: %s", err)
+	}
+	err = svg.ChangeBase("08475")
+	if !errors.Is(err, ErrBadID) {
+		t.Errorf("ChangeBase: expected ErrBadID, got %v", err)
+	}
+}
+
+func TestCorpus(t *testing.T) {
+	dir := bin() + "/t"
+	c, err := OpenCorpus(dir)
+	if err != nil {
+		t.Fatalf("Error opening %s: %s", dir, err)
+	}
+	files, err := c.Files()
+	if err != nil || len(files) != 1 || files[0] != dir+"/08475.svg" {
+		t.Errorf("Files: got %v, %v", files, err)
+	}
+	if c.Rel(files[0]) != "08475.svg" {
+		t.Errorf("Rel: got %s", c.Rel(files[0]))
+	}
+	svg, err := c.Lookup('葵')
+	if err != nil {
+		t.Fatalf("Lookup: %s", err)
+	}
+	if svg.BaseGroup().Element != "葵" {
+		t.Errorf("Lookup: wrong element %s", svg.BaseGroup().Element)
+	}
+	variants, err := c.Variants('葵')
+	if err != nil || len(variants) != 0 {
+		t.Errorf("Variants: got %v, %v", variants, err)
+	}
+	fsc := NewCorpus(os.DirFS(dir))
+	n := 0
+	err = fsc.ExamineAllFiles(func(file string, svg SVG) {
+		if file != "08475.svg" {
+			t.Errorf("ExamineAllFiles: unexpected file name %s", file)
+		}
+		n++
+	})
+	if err != nil || n != 1 {
+		t.Errorf("ExamineAllFiles: %d files, %v", n, err)
+	}
+	t.Setenv(CorpusEnv, "")
+	_, err = OpenEnvCorpus()
+	if !errors.Is(err, ErrNoCorpus) {
+		t.Errorf("OpenEnvCorpus: expected ErrNoCorpus, got %v", err)
+	}
+}
+
+func TestWalk(t *testing.T) {
+	contents := []byte(read(bin() + "/t/08475.svg"))
+	fsys := fstest.MapFS{}
+	for i := 0; i < 20; i++ {
+		fsys[fmt.Sprintf("%05x.svg", 0x8475+i)] = &fstest.MapFile{Data: contents}
+	}
+	fsys["08480-Bad.svg"] = &fstest.MapFile{Data: []byte("<svg><g")}
+	c := NewCorpus(fsys)
+	var out bytes.Buffer
+	opt := WalkOptions{Workers: 4, Ordered: true, Output: &out}
+	err := c.Walk(context.Background(), opt, func(f *WalkFile) error {
+		fmt.Fprintf(&f.Out, "%s\n", f.Name)
+		return nil
+	})
+	var errs WalkErrors
+	if !errors.As(err, &errs) || len(errs) != 1 || errs[0].File != "08480-Bad.svg" {
+		t.Errorf("Walk: expected one error for 08480-Bad.svg, got %v", err)
+	}
+	files, _ := c.Files()
+	var expect string
+	for _, file := range files {
+		if file != "08480-Bad.svg" {
+			expect += file + "\n"
+		}
+	}
+	if out.String() != expect {
+		t.Errorf("Walk: output out of order:\n%s", out.String())
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = c.Walk(ctx, WalkOptions{}, func(f *WalkFile) error {
+		return nil
+	})
+	if err != context.Canceled {
+		t.Errorf("Walk: expected context.Canceled, got %v", err)
+	}
+}
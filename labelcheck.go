@@ -0,0 +1,160 @@
+package kvg
+
+import "fmt"
+
+// Checking the stroke-number labels.
+
+// The things which can be wrong with the labels.
+type LabelProblem int
+
+const (
+	// The number of labels is not the number of strokes.
+	LabelCount LabelProblem = iota
+	// The label is not a number.
+	LabelNotNumber
+	// The number of the label is not its position among the labels.
+	LabelOutOfOrder
+	// The label is nearer to the start of another stroke than to the
+	// start of its own.
+	LabelNearerOther
+	// The label overlaps an earlier label.
+	LabelOverlap
+	// The label is on top of a stroke.
+	LabelOnStroke
+	// The transform of the label could not be read, so its place is
+	// not known.
+	LabelBadTransform
+)
+
+var labelProblemNames = []string{
+	"wrong number of labels",
+	"not a number",
+	"out of order",
+	"nearer another stroke",
+	"overlaps another label",
+	"on a stroke",
+	"bad transform",
+}
+
+func (k LabelProblem) String() string {
+	if k < 0 || int(k) >= len(labelProblemNames) {
+		return fmt.Sprintf("LabelProblem(%d)", int(k))
+	}
+	return labelProblemNames[k]
+}
+
+// A problem with the labels of a file.
+type LabelError struct {
+	// The file the SVG was read from.
+	File    string
+	Problem LabelProblem
+	// The content of the label, or empty for LabelCount.
+	Label string
+	// More about the problem, such as the other label for
+	// LabelOverlap.
+	Detail string
+	// The label, or nil for LabelCount.
+	text *Text
+}
+
+func (e *LabelError) Error() string {
+	s := e.File + ": "
+	if e.text != nil {
+		s += fmt.Sprintf("label %q: ", e.Label)
+	}
+	s += e.Problem.String()
+	if e.Detail != "" {
+		s += ": " + e.Detail
+	}
+	return s
+}
+
+// Unwrap returns ErrBadLabel.
+func (e *LabelError) Unwrap() error {
+	return ErrBadLabel
+}
+
+// Half the width of the KanjiVG strokes. A label nearer than this to
+// the middle of a stroke is on top of it.
+const strokeHalfWidth = 1.5
+
+// Check the labels of svg. The boxes of the labels are from the font
+// size of lp. A label which is not a number, is out of order or has a
+// transform which could not be read is not checked for its place. The
+// file name is only used in the errors.
+func (svg *SVG) CheckLabels(file string, lp LabelPlacement) (errs []*LabelError, err error) {
+	pl, err := svg.newLabelPlacer(lp)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	labels := svg.Labels()
+	if len(labels) != len(pl.lines) {
+		errs = append(errs, &LabelError{File: file, Problem: LabelCount,
+			Detail: fmt.Sprintf("%d labels for %d strokes", len(labels), len(pl.lines))})
+	}
+	bad := func(t *Text, problem LabelProblem, detail string) {
+		errs = append(errs, &LabelError{File: file, Problem: problem,
+			Label: string(t.Content), Detail: detail, text: t})
+	}
+	var placed []*Text
+	for i, t := range labels {
+		n, err := t.Number()
+		if err != nil {
+			bad(t, LabelNotNumber, "")
+			continue
+		}
+		if n != i+1 {
+			bad(t, LabelOutOfOrder, fmt.Sprintf("expected %d", i+1))
+			continue
+		}
+		if n > len(pl.lines) {
+			continue
+		}
+		if !t.Transform.Valid() {
+			bad(t, LabelBadTransform, fmt.Sprintf("%q", t.Transform.String()))
+			continue
+		}
+		box := lp.LabelBox(t)
+		if j, d, own := pl.nearerOther(n-1, box); j >= 0 {
+			bad(t, LabelNearerOther, fmt.Sprintf("%.2f from stroke %d, %.2f from its own", d, j+1, own))
+		}
+		for _, o := range placed {
+			if rectDistance(box, lp.LabelBox(o)) == 0 {
+				bad(t, LabelOverlap, fmt.Sprintf("label %q", o.Content))
+				break
+			}
+		}
+		for j, l := range pl.lines {
+			if d := l.rectDistance(box); d < strokeHalfWidth {
+				bad(t, LabelOnStroke, fmt.Sprintf("stroke %d", j+1))
+				break
+			}
+		}
+		placed = append(placed, t)
+	}
+	return errs, nil
+}
+
+// Put right the problems errs found by CheckLabels, using the rules of
+// PlaceLabels. If the number of labels is wrong or any label is not a
+// number or out of order, all of the labels are made again. Otherwise
+// only the labels which are misplaced are moved, and the others stay
+// where they are. A transform which could not be read is replaced.
+func (svg *SVG) FixLabels(lp LabelPlacement, errs []*LabelError) (err error) {
+	var move []*Text
+	moving := map[*Text]bool{}
+	for _, e := range errs {
+		switch e.Problem {
+		case LabelCount, LabelNotNumber, LabelOutOfOrder:
+			return svg.PlaceLabels(lp)
+		}
+		if !moving[e.text] {
+			moving[e.text] = true
+			move = append(move, e.text)
+		}
+	}
+	if len(move) == 0 {
+		return nil
+	}
+	return svg.MoveLabels(lp, move)
+}
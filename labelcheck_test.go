@@ -0,0 +1,100 @@
+package kvg
+
+import (
+	"encoding/xml"
+	"errors"
+	"testing"
+)
+
+// Get the problems of the labels of svg.
+func labelProblems(t *testing.T, svg *SVG) (problems []LabelProblem) {
+	t.Helper()
+	errs, err := svg.CheckLabels("08475.svg", DefaultLabelPlacement)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range errs {
+		problems = append(problems, e.Problem)
+	}
+	return problems
+}
+
+func TestCheckLabels(t *testing.T) {
+	svg, err := ReadKanjiFile(bin() + "/t/08475.svg")
+	if err != nil {
+		t.Fatalf("Error reading: %s", err)
+	}
+	err = svg.PlaceLabels(DefaultLabelPlacement)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p := labelProblems(t, &svg); len(p) != 0 {
+		t.Fatalf("Placed labels have problems %v", p)
+	}
+	labels := svg.Labels()
+	middle, _, err := svg.GetPaths()[3].PointAt(0.5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range []struct {
+		edit    func()
+		problem LabelProblem
+	}{
+		{func() { labels[3].Content = []byte("x") }, LabelNotNumber},
+		{func() { labels[3].SetNumber(5) }, LabelOutOfOrder},
+		{func() { labels[3].SetPosition(labels[2].Position()) }, LabelNearerOther},
+		{func() { labels[3].SetPosition(labels[4].Position().add(Point{1, 1})) }, LabelOverlap},
+		{func() { labels[3].SetPosition(middle.add(Point{-2, 2})) }, LabelOnStroke},
+		{func() { labels[3].Transform.UnmarshalXMLAttr(xml.Attr{Value: "move(1 2)"}) }, LabelBadTransform},
+	} {
+		saved := *labels[3]
+		test.edit()
+		problems := labelProblems(t, &svg)
+		found := false
+		for _, p := range problems {
+			found = found || p == test.problem
+		}
+		if !found {
+			t.Errorf("Expected %s, got %v", test.problem, problems)
+		}
+		*labels[3] = saved
+	}
+	svg.Groups[1].Children = svg.Groups[1].Children[1:]
+	errs, err := svg.CheckLabels("08475.svg", DefaultLabelPlacement)
+	if err != nil || len(errs) == 0 || errs[0].Problem != LabelCount {
+		t.Fatalf("Expected a wrong number of labels, got %v, %v", errs, err)
+	}
+	if !errors.Is(errs[0], ErrBadLabel) {
+		t.Errorf("%s is not ErrBadLabel", errs[0])
+	}
+}
+
+func TestFixLabels(t *testing.T) {
+	svg, err := ReadKanjiFile(bin() + "/t/08475.svg")
+	if err != nil {
+		t.Fatalf("Error reading: %s", err)
+	}
+	svg.Labels()[0].Transform.UnmarshalXMLAttr(xml.Attr{Value: "move(1 2)"})
+	errs, err := svg.CheckLabels("08475.svg", DefaultLabelPlacement)
+	if err != nil || len(errs) == 0 {
+		t.Fatalf("Expected problems with 08475.svg, got %v, %v", errs, err)
+	}
+	before := transforms(&svg)
+	moved := map[string]bool{}
+	for _, e := range errs {
+		moved[e.Label] = true
+	}
+	err = svg.FixLabels(DefaultLabelPlacement, errs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, tr := range transforms(&svg) {
+		label := string(svg.Labels()[i].Content)
+		if !moved[label] && tr != before[i] {
+			t.Errorf("Label %s moved from %s to %s", label, before[i], tr)
+		}
+	}
+	if p := labelProblems(t, &svg); len(p) != 0 {
+		t.Errorf("Fixed labels have problems %v", p)
+	}
+}
@@ -0,0 +1,234 @@
+package kvg
+
+import (
+	"encoding/xml"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// The stroke-number labels, and their transform attributes.
+
+// One transform of a transform attribute, such as "translate(4 5)".
+type TransformOp struct {
+	// One of "matrix", "translate", "scale", "rotate", "skewX" and
+	// "skewY".
+	Name   string
+	Values []float64
+}
+
+// Get the Affine of op, or false if op has the wrong number of values
+// for its name.
+func (op TransformOp) Affine() (m Affine, ok bool) {
+	return makeTransform(op.Name, op.Values)
+}
+
+// The value of a transform attribute, such as "matrix(1 0 0 1 4.25
+// 16.63)", which is the form used by all the labels of the KanjiVG
+// files.
+type Transform struct {
+	// The transforms of the list, in the order they are written.
+	// They are applied from last to first.
+	Ops []TransformOp
+	// The attribute as it was read, and the transforms read from
+	// it, so that a transform which has not been changed is written
+	// back as it was. A transform which could not be read has raw
+	// but no rawOps.
+	raw    string
+	rawOps []TransformOp
+}
+
+// One transform of a transform attribute, such as "translate(10 20)".
+var transformRe = regexp.MustCompile(`^[\s,]*(matrix|translate|scale|rotate|skewX|skewY)\s*\(([^)]*)\)`)
+
+// Read the value of a transform attribute, which is a list of
+// transforms such as "translate(10 20) scale(2)".
+func ParseTransformList(s string) (t Transform, err error) {
+	rest := s
+	for strings.TrimLeft(rest, " \t\r\n,") != "" {
+		match := transformRe.FindStringSubmatch(rest)
+		if match == nil {
+			return Transform{}, fmt.Errorf("bad transform %q", s)
+		}
+		rest = rest[len(match[0]):]
+		op := TransformOp{Name: match[1]}
+		for _, f := range strings.FieldsFunc(match[2], func(r rune) bool {
+			return r == ',' || r == ' ' || r == '\t' || r == '\r' || r == '\n'
+		}) {
+			x, err := strconv.ParseFloat(f, 64)
+			if err != nil {
+				return Transform{}, fmt.Errorf("bad transform %q: %w", s, err)
+			}
+			op.Values = append(op.Values, x)
+		}
+		if _, ok := op.Affine(); !ok {
+			return Transform{}, fmt.Errorf("bad transform %q: wrong number of values for %s", s, op.Name)
+		}
+		t.Ops = append(t.Ops, op)
+	}
+	t.raw = s
+	t.rawOps = copyOps(t.Ops)
+	return t, nil
+}
+
+func copyOps(ops []TransformOp) (c []TransformOp) {
+	for _, op := range ops {
+		c = append(c, TransformOp{op.Name, append([]float64{}, op.Values...)})
+	}
+	return c
+}
+
+func sameOps(a, b []TransformOp) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].Name != b[i].Name || len(a[i].Values) != len(b[i].Values) {
+			return false
+		}
+		for j, v := range a[i].Values {
+			if v != b[i].Values[j] {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Could t be read? A transform which could not be read is written
+// back as it was, but has no Ops.
+func (t Transform) Valid() bool {
+	return t.raw == "" || t.rawOps != nil
+}
+
+// Get the single Affine which does all the transforms of t. A
+// transform with the wrong number of values is left out.
+func (t Transform) Affine() (m Affine) {
+	m = Identity
+	for _, op := range t.Ops {
+		a, ok := op.Affine()
+		if !ok {
+			continue
+		}
+		// The transforms of a list are applied from right to left.
+		m = a.Then(m)
+	}
+	return m
+}
+
+// Write t as the value of a transform attribute. A transform which
+// has not been changed since it was read is written as it was read.
+// Otherwise the translations, and the last two numbers of a matrix,
+// are written to two decimal places, like the labels of the KanjiVG
+// files, and the other numbers to four.
+func (t Transform) String() string {
+	if t.raw != "" && (t.rawOps == nil || sameOps(t.Ops, t.rawOps)) {
+		return t.raw
+	}
+	f := PathFormat{Precision: 4}
+	var ops []string
+	for _, op := range t.Ops {
+		var vs []string
+		for i, v := range op.Values {
+			if op.Name == "translate" || op.Name == "matrix" && i >= 4 {
+				vs = append(vs, shortFloat(v))
+			} else {
+				vs = append(vs, f.number(v))
+			}
+		}
+		ops = append(ops, op.Name+"("+strings.Join(vs, " ")+")")
+	}
+	return strings.Join(ops, " ")
+}
+
+// Read t from an attribute. A transform which cannot be read is kept
+// as it is, so that it is written back unchanged, and Valid gives
+// false.
+func (t *Transform) UnmarshalXMLAttr(attr xml.Attr) error {
+	parsed, err := ParseTransformList(attr.Value)
+	if err != nil {
+		*t = Transform{raw: attr.Value}
+		return nil
+	}
+	*t = parsed
+	return nil
+}
+
+// Write t as an attribute, which is left out if t is empty.
+func (t Transform) MarshalXMLAttr(name xml.Name) (xml.Attr, error) {
+	s := t.String()
+	if s == "" {
+		return xml.Attr{}, nil
+	}
+	return xml.Attr{Name: name, Value: s}, nil
+}
+
+// Get the position of the label t, which is where its transform puts
+// the origin.
+func (t *Text) Position() Point {
+	return t.Transform.Affine().Point(Point{})
+}
+
+// Move the label t to p, keeping the rest of its transform. If the
+// transform of t could not be parsed, it is thrown away and replaced
+// by one which only moves t to p, since the Affine of a transform
+// which could not be parsed is Identity.
+func (t *Text) SetPosition(p Point) {
+	m := t.Transform.Affine()
+	m.E, m.F = p.X, p.Y
+	t.Transform = m.Transform()
+}
+
+// Get the number of the label t, such as 3 for "3".
+func (t *Text) Number() (n int, err error) {
+	return strconv.Atoi(strings.TrimSpace(string(t.Content)))
+}
+
+// Set the number of the label t.
+func (t *Text) SetNumber(n int) {
+	t.Content = []byte(strconv.Itoa(n))
+}
+
+// Get the stroke-number labels of svg, in order.
+func (svg *SVG) Labels() (labels []*Text) {
+	if len(svg.Groups) < 2 {
+		return nil
+	}
+	for i := range svg.Groups[1].Children {
+		c := &svg.Groups[1].Children[i]
+		if c.IsText {
+			labels = append(labels, &c.Text)
+		}
+	}
+	return labels
+}
+
+// Get the label of p, which is the label whose number is the position
+// of p among the strokes, or nil if there is none.
+func (svg *SVG) LabelOf(p *Path) *Text {
+	for i, q := range svg.GetPaths() {
+		if q != p {
+			continue
+		}
+		for _, t := range svg.Labels() {
+			if n, err := t.Number(); err == nil && n == i+1 {
+				return t
+			}
+		}
+	}
+	return nil
+}
+
+// Get the path which the label t numbers, or nil if there is none.
+func (svg *SVG) PathOf(t *Text) *Path {
+	n, err := t.Number()
+	if err != nil {
+		return nil
+	}
+	paths := svg.GetPaths()
+	if n < 1 || n > len(paths) {
+		return nil
+	}
+	return paths[n-1]
+}
@@ -0,0 +1,86 @@
+package kvg
+
+import (
+	"encoding/xml"
+	"testing"
+)
+
+func TestTransform(t *testing.T) {
+	for _, test := range []struct {
+		in   string
+		ops  int
+		at   Point
+		good bool
+	}{
+		{"matrix(1 0 0 1 11.5 16.13)", 1, Point{11.5, 16.13}, true},
+		{"translate(4,5)", 1, Point{4, 5}, true},
+		{"translate(10) scale(2)", 2, Point{10, 0}, true},
+		{"scale(2) translate(10 1)", 2, Point{20, 2}, true},
+		{"", 0, Point{}, true},
+		{"matrix(1 0 0 1 4)", 0, Point{}, false},
+		{"shift(3)", 0, Point{}, false},
+	} {
+		var tr Transform
+		tr.UnmarshalXMLAttr(xml.Attr{Value: test.in})
+		if tr.Valid() != test.good {
+			t.Errorf("%q: expected valid %t", test.in, test.good)
+		}
+		if len(tr.Ops) != test.ops {
+			t.Errorf("%q: expected %d ops, got %d", test.in, test.ops, len(tr.Ops))
+		}
+		text := Text{Transform: tr}
+		if !near(text.Position(), test.at) {
+			t.Errorf("%q: expected position %v, got %v", test.in, test.at, text.Position())
+		}
+		if tr.String() != test.in {
+			t.Errorf("%q: written back as %q", test.in, tr.String())
+		}
+	}
+	tr, err := ParseTransformList("translate(4 5)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr.Ops[0].Values[1] = 6.127
+	if tr.String() != "translate(4 6.13)" {
+		t.Errorf("Changed transform written as %q", tr.String())
+	}
+}
+
+func TestLabels(t *testing.T) {
+	svg, err := ReadKanjiFile(bin() + "/t/08475.svg")
+	if err != nil {
+		t.Fatalf("Error reading: %s", err)
+	}
+	labels := svg.Labels()
+	paths := svg.GetPaths()
+	if len(labels) != len(paths) {
+		t.Fatalf("Expected %d labels, got %d", len(paths), len(labels))
+	}
+	for i, label := range labels {
+		n, err := label.Number()
+		if err != nil || n != i+1 {
+			t.Errorf("Label %d has number %d, %v", i+1, n, err)
+		}
+		if svg.PathOf(label) != paths[i] {
+			t.Errorf("Label %d is not linked to path %s", i+1, paths[i].ID)
+		}
+		if svg.LabelOf(paths[i]) != label {
+			t.Errorf("Path %s is not linked to label %d", paths[i].ID, i+1)
+		}
+	}
+	label := labels[0]
+	label.SetPosition(Point{20, 30.5})
+	if label.Transform.String() != "matrix(1 0 0 1 20 30.5)" {
+		t.Errorf("Moved label has transform %q", label.Transform.String())
+	}
+	label.Transform = Transform{}
+	label.Transform.UnmarshalXMLAttr(xml.Attr{Value: "matrix(1 0 0 1 junk)"})
+	label.SetPosition(Point{5, 6})
+	if !label.Transform.Valid() || label.Transform.String() != "matrix(1 0 0 1 5 6)" {
+		t.Errorf("Bad transform was not replaced: %q", label.Transform.String())
+	}
+	label.SetNumber(len(paths) + 1)
+	if svg.PathOf(label) != nil || svg.LabelOf(paths[0]) != nil {
+		t.Errorf("Label with no path is linked")
+	}
+}
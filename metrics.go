@@ -0,0 +1,92 @@
+package kvg
+
+import "math"
+
+// Measurements of the shape of a stroke.
+
+// The measurements of a stroke made by Metrics.
+type StrokeMetrics struct {
+	// The length of the stroke along its curves.
+	Length float64
+	// Where the stroke starts and ends.
+	Start, End Point
+	// The directions in which the stroke starts and ends, as vectors
+	// of length one.
+	StartTangent, EndTangent Point
+	// The sum of the angles in radians by which the stroke turns
+	// along its length, with turns which are clockwise as seen on the
+	// screen positive and anticlockwise ones negative, so a stroke
+	// which bends one way and then back has a Turning near zero.
+	Turning float64
+	// The sum of the sizes of the turns, so that every bend adds to
+	// it.
+	TotalTurning float64
+	// The greatest curvature, which is one over the radius of the
+	// curve, anywhere along the curves of the stroke, and where it is.
+	// The sharp corners between the curves of a path, as in the
+	// strokes which turn through a right angle, are not included, but
+	// they are counted in the turning.
+	MaxCurvature      float64
+	MaxCurvaturePoint Point
+}
+
+// The number of points of each curve at which the curvature is found.
+const curvatureSamples = 64
+
+// Get the measurements of p. The length and the turning are measured
+// along the polyline made by Flatten with the given tolerance. A path
+// with no curves has zero measurements.
+func (p SVGPath) Metrics(tolerance float64) (m StrokeMetrics) {
+	segs := p.segments()
+	if len(segs) == 0 {
+		return m
+	}
+	first, last := segs[0], segs[len(segs)-1]
+	m.Start, m.End = first.p[0], last.end()
+	m.StartTangent, m.EndTangent = first.tangent(0), last.tangent(1)
+	// The turning goes from the start tangent through each piece of
+	// the polyline to the end tangent.
+	prev := m.StartTangent
+	turn := func(d Point) {
+		a := angle(prev.X, prev.Y, d.X, d.Y)
+		m.Turning += a
+		m.TotalTurning += math.Abs(a)
+		prev = d
+	}
+	line := p.Flatten(tolerance)
+	for i := 1; i < len(line); i++ {
+		d := line[i].sub(line[i-1])
+		if d == (Point{}) {
+			continue
+		}
+		m.Length += d.length()
+		turn(d)
+	}
+	turn(m.EndTangent)
+	m.MaxCurvaturePoint = m.Start
+	for _, s := range segs {
+		d1, d2 := s.hodograph(), s.hodograph().hodograph()
+		for i := 0; i <= curvatureSamples; i++ {
+			t := float64(i) / curvatureSamples
+			v, a := d1.point(t), d2.point(t)
+			speed := v.length()
+			if speed < 1e-9 {
+				continue
+			}
+			k := math.Abs(v.X*a.Y-v.Y*a.X) / (speed * speed * speed)
+			if k > m.MaxCurvature {
+				m.MaxCurvature, m.MaxCurvaturePoint = k, s.point(t)
+			}
+		}
+	}
+	return m
+}
+
+// Get the measurements of p. See SVGPath.Metrics.
+func (p *Path) Metrics(tolerance float64) (m StrokeMetrics, err error) {
+	path, err := p.Parse()
+	if err != nil {
+		return m, err
+	}
+	return path.Metrics(tolerance), nil
+}
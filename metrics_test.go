@@ -0,0 +1,40 @@
+package kvg
+
+import (
+	"math"
+	"testing"
+)
+
+func TestMetrics(t *testing.T) {
+	// A line to the right and then down, which turns clockwise on the
+	// screen through a right angle.
+	p := Path{D: "M10,10h20v20"}
+	m, err := p.Metrics(0.01)
+	if err != nil {
+		t.Fatalf("Error measuring %s: %s", p.D, err)
+	}
+	if m.Length != 40 || m.Start != (Point{10, 10}) || m.End != (Point{30, 30}) {
+		t.Errorf("Bad length %g or ends %v %v", m.Length, m.Start, m.End)
+	}
+	if m.StartTangent != (Point{1, 0}) || m.EndTangent != (Point{0, 1}) {
+		t.Errorf("Bad tangents %v %v", m.StartTangent, m.EndTangent)
+	}
+	if math.Abs(m.Turning-math.Pi/2) > 1e-9 || m.TotalTurning != m.Turning {
+		t.Errorf("Bad turning %g %g", m.Turning, m.TotalTurning)
+	}
+	if m.MaxCurvature != 0 {
+		t.Errorf("Straight lines have curvature %g", m.MaxCurvature)
+	}
+	// A quarter circle of radius 10, anticlockwise on the screen.
+	p.D = "M10,0A10,10,0,0,0,0,10"
+	m, _ = p.Metrics(0.001)
+	if math.Abs(m.Length-5*math.Pi) > 0.01 {
+		t.Errorf("Quarter circle has length %g", m.Length)
+	}
+	if math.Abs(m.Turning+math.Pi/2) > 0.01 {
+		t.Errorf("Quarter circle turns by %g", m.Turning)
+	}
+	if math.Abs(m.MaxCurvature-0.1) > 0.001 {
+		t.Errorf("Quarter circle has curvature %g", m.MaxCurvature)
+	}
+}
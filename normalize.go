@@ -0,0 +1,114 @@
+package kvg
+
+import (
+	"sort"
+	"strings"
+)
+
+// Normalization of the kvg:element values.
+//
+// The same component may be written in several ways in the KanjiVG
+// files: as a unified ideograph such as 氵, as a CJK Radicals
+// Supplement character such as ⺡, as a Kangxi Radicals character such
+// as ⽔, or as a CJK compatibility ideograph. The canonical form of an
+// element replaces each of these with the unified ideograph, so that
+// elements can be compared. The display form goes the other way, and
+// uses the radical character where there is one.
+
+// A function which changes an element before it is compared, such as
+// CanonicalElement. A nil ElementNormalizer leaves the element as it
+// is.
+type ElementNormalizer func(el string) string
+
+// Apply norm to el, if norm is not nil.
+func (norm ElementNormalizer) apply(el string) string {
+	if norm == nil {
+		return el
+	}
+	return norm(el)
+}
+
+var (
+	// The unified ideograph of each variant form.
+	toUnified map[rune]rune
+	// The display form of each unified ideograph which has one.
+	toDisplay map[rune]rune
+	// All of the variant forms of each unified ideograph.
+	variantForms map[rune][]rune
+)
+
+func init() {
+	toUnified = make(map[rune]rune)
+	toDisplay = make(map[rune]rune)
+	variantForms = make(map[rune][]rune)
+	add := func(from, to rune) {
+		toUnified[from] = to
+		variantForms[to] = append(variantForms[to], from)
+	}
+	// A Kangxi radical is preferred as the display form of a
+	// unified ideograph which is itself a radical, such as 月.
+	for from, to := range kangxiRadicals {
+		add(from, to)
+		toDisplay[to] = from
+	}
+	for _, pair := range radicalsSupplement {
+		add(pair[0], pair[1])
+		if _, ok := toDisplay[pair[1]]; !ok {
+			toDisplay[pair[1]] = pair[0]
+		}
+	}
+	for from, to := range compatIdeographs {
+		add(from, to)
+	}
+	for _, forms := range variantForms {
+		sort.Slice(forms, func(i, j int) bool {
+			return forms[i] < forms[j]
+		})
+	}
+}
+
+// Get the canonical form of el, in which each radical character or
+// compatibility ideograph is replaced by the unified ideograph.
+func CanonicalElement(el string) string {
+	return strings.Map(func(r rune) rune {
+		if u, ok := toUnified[r]; ok {
+			return u
+		}
+		return r
+	}, el)
+}
+
+// Get the display form of el, in which each character which has a
+// radical form is replaced by that form, such as 氵 by ⺡ and 月 by ⽉.
+// The display form of the canonical form of an element is not always
+// the original element, since for example ⺼ and ⺝ both have the
+// canonical form 月.
+func DisplayElement(el string) string {
+	return strings.Map(func(r rune) rune {
+		if d, ok := toDisplay[r]; ok {
+			return d
+		}
+		return r
+	}, CanonicalElement(el))
+}
+
+// Get all of the forms of the single-character element el, starting
+// with the canonical form. An element of more than one character has
+// only its canonical form.
+func ElementForms(el string) (forms []string) {
+	canon := CanonicalElement(el)
+	forms = []string{canon}
+	runes := []rune(canon)
+	if len(runes) != 1 {
+		return forms
+	}
+	for _, r := range variantForms[runes[0]] {
+		forms = append(forms, string(r))
+	}
+	return forms
+}
+
+// Are a and b forms of the same element?
+func SameElement(a, b string) bool {
+	return CanonicalElement(a) == CanonicalElement(b)
+}
@@ -0,0 +1,50 @@
+package kvg
+
+import "testing"
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		el, canon, display string
+	}{
+		{"⺡", "氵", "⺡"},
+		{"氵", "氵", "⺡"},
+		{"⽔", "水", "⽔"},
+		{"⺼", "月", "⽉"},
+		{"令", "令", "令"}, // 令
+		{"葵", "葵", "葵"},
+		{"⺾口", "艹口", "⺾⼝"},
+	}
+	for _, test := range tests {
+		canon := CanonicalElement(test.el)
+		if canon != test.canon {
+			t.Errorf("Canonical form of %s is %s not %s", test.el, canon, test.canon)
+		}
+		display := DisplayElement(test.el)
+		if display != test.display {
+			t.Errorf("Display form of %s is %s not %s", test.el, display, test.display)
+		}
+	}
+	forms := ElementForms("⺿")
+	if len(forms) < 4 || forms[0] != "艹" || forms[1] != "⺾" || forms[3] != "⻀" {
+		t.Errorf("Wrong forms %v", forms)
+	}
+	if !SameElement("⻌", "辶") || SameElement("月", "日") {
+		t.Errorf("SameElement failed")
+	}
+	svg, err := ReadKanjiFile(bin() + "/t/08475.svg")
+	if err != nil {
+		t.Fatalf("Error reading: %s", err)
+	}
+	base := svg.BaseGroup()
+	found, _ := base.FindElement("⺾")
+	if found {
+		t.Errorf("Found ⺾ without normalizing")
+	}
+	found, loc := base.FindElementNorm("⺾", CanonicalElement)
+	if !found || loc[0].ID != "kvg:08475-g1" {
+		t.Errorf("Did not find ⺾ with normalizing")
+	}
+	if len(base.SubgroupsNorm(DisplayElement)["⺾"]) != 1 {
+		t.Errorf("SubgroupsNorm failed")
+	}
+}
@@ -1,15 +1,18 @@
 package kvg
 
 import (
+	"fmt"
 	"strconv"
 	"strings"
+	"unicode/utf8"
 )
 
-// structure containing information about path commands as by specification
-var allCommands commands
+// structure containing information about path commands as by
+// specification. This is never changed after it is made, so paths may
+// be parsed from several goroutines at once.
+var allCommands = getCommands()
 
 type commands struct {
-	all        []string
 	parameters map[string]int
 	start      string
 	end        string
@@ -20,42 +23,54 @@ func getCommands() commands {
 		"m": 2, "z": 0, "l": 2, "h": 1, "v": 1,
 		"c": 6, "s": 4, "q": 4, "t": 2, "a": 7,
 	}
-	var all []string
-	for k := range parameters {
-		all = append(all, k)
-	}
-	return commands{all, parameters, "m", "z"}
+	return commands{parameters, "m", "z"}
 }
 
-func (c commands) isCommand(token string) bool {
-	for _, command := range c.all {
-		if strings.ToLower(token) == command {
-			return true
-		}
+// Get the number of parameters of the command with symbol b, or false
+// if b is not the symbol of a command.
+func commandParams(b byte) (n int, ok bool) {
+	if b >= 'A' && b <= 'Z' {
+		b += 'a' - 'A'
 	}
-	return false
+	n, ok = allCommands.parameters[string(rune(b))]
+	return n, ok
 }
 
 // PathParserError contains errors which have occured when parsing 'd'
 // attribute of a path element.
 type PathParserError struct {
 	msg string
+	// The position in bytes in the 'd' attribute of the error.
+	Offset int
+	// The symbol of the command being read when the error was found,
+	// or the empty string if the error is before the first command.
+	Command string
 }
 
 func (err PathParserError) Error() string {
-	return err.msg
+	if err.Command == "" {
+		return fmt.Sprintf("%s at byte %d", err.msg, err.Offset)
+	}
+	return fmt.Sprintf("%s in command %s at byte %d", err.msg, err.Command, err.Offset)
 }
 
 // token can contain an operator or an operand as string.
 type token struct {
 	value    string
 	operator bool
+	// The position of the token in the 'd' attribute.
+	offset int
 }
 
 // Command is a representation of an SVG path command and its parameters.
 type Command struct {
 	Symbol string
 	Params []float64
+	// True if the symbol was left out of the 'd' attribute because
+	// the command repeats the one before it, as in "c1,2,3,4,5,6
+	// 7,8,9,10,11,12". The parameters after a moveto are implicit
+	// linetos.
+	Implicit bool
 }
 
 // IsAbsolute returns true if the SVG path command is absolute.
@@ -113,96 +128,177 @@ func (p SVGPath) Compare(o SVGPath) bool {
 	return true
 }
 
-func reverse(ops []float64) []float64 {
-	for i, j := 0, len(ops)-1; i < j; i, j = i+1, j-1 {
-		ops[i], ops[j] = ops[j], ops[i]
-	}
-	return ops
+// Is c white space in the SVG path grammar?
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f'
 }
 
-func addOperand(tokens []token, operand string) ([]token, string) {
-	if operand != "" {
-		tokens = append(tokens, token{operand, false})
-		operand = ""
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// Find the end of the number starting at raw[i], which is
+//
+//	sign? (digits ("." digits?)? | "." digits) (("e" | "E") sign? digits)?
+//
+// or return false if there is no number at raw[i].
+func scanNumber(raw string, i int) (end int, ok bool) {
+	digits := func() int {
+		start := i
+		for i < len(raw) && isDigit(raw[i]) {
+			i++
+		}
+		return i - start
+	}
+	if i < len(raw) && (raw[i] == '+' || raw[i] == '-') {
+		i++
 	}
-	return tokens, operand
+	n := digits()
+	if i < len(raw) && raw[i] == '.' {
+		i++
+		n += digits()
+	}
+	if n == 0 {
+		return i, false
+	}
+	if i < len(raw) && (raw[i] == 'e' || raw[i] == 'E') {
+		i++
+		if i < len(raw) && (raw[i] == '+' || raw[i] == '-') {
+			i++
+		}
+		if digits() == 0 {
+			return i, false
+		}
+	}
+	return i, true
 }
 
 // tokenize takes value of 'd' attribute and transforms it to series of
-// operators and operands - step 1.
-func tokenize(raw string) []token {
+// operators and operands - step 1. This follows the grammar of the SVG
+// specification, so the path must start with a moveto, numbers are
+// separated by white space or a single comma where they need to be,
+// and the flags of an arc are single digits which need not be
+// separated, as in "a1,1 0 00 1,1".
+func tokenize(raw string) (tokens []token, err error) {
 	var (
-		tokens  []token
-		operand string
+		// The current command and the number of operands it has had.
+		command string
+		index   int
+		// The position of a comma which needs a number after it, or
+		// -1.
+		comma = -1
 	)
-	for _, r := range raw {
-		char := string(r)
+	fail := func(i int, format string, a ...interface{}) error {
+		return PathParserError{fmt.Sprintf(format, a...), i, command}
+	}
+	// The number of parameters of the current command.
+	nParam := 0
+	tokens = make([]token, 0, len(raw)/3)
+	for i := 0; i < len(raw); {
+		c := raw[i]
+		n, isCommand := commandParams(c)
 		switch {
-		case allCommands.isCommand(char):
-			tokens, operand = addOperand(tokens, operand)
-			tokens = append(tokens, token{char, true})
-		case char == ".":
-			if operand == "" {
-				operand = "0"
+		case isSpace(c):
+			i++
+		case c == ',':
+			if index == 0 || comma >= 0 {
+				return tokens, fail(i, "unexpected comma")
+			}
+			comma = i
+			i++
+		case isCommand:
+			if comma >= 0 {
+				return tokens, fail(comma, "comma before command")
+			}
+			if command == "" && c != 'm' && c != 'M' {
+				return tokens, fail(i, "path does not start with a moveto")
 			}
-			if strings.Contains(operand, char) {
-				tokens = append(tokens, token{operand, false})
-				operand = "0"
+			command = raw[i : i+1]
+			nParam = n
+			index = 0
+			tokens = append(tokens, token{command, true, i})
+			i++
+		case command == "" && (isDigit(c) || c == '.' || c == '+' || c == '-'):
+			return tokens, fail(i, "number before the first command")
+		case nParam == 7 && (index%7 == 3 || index%7 == 4):
+			if c != '0' && c != '1' {
+				return tokens, fail(i, "bad arc flag %q", c)
 			}
-			fallthrough
-		case char >= "0" && char <= "9" || char == "e":
-			operand += char
-		case char == "-":
-			if strings.HasSuffix(operand, "e") {
-				operand += char
-			} else {
-				tokens, operand = addOperand(tokens, operand)
-				operand = char
+			tokens = append(tokens, token{string(c), false, i})
+			index++
+			comma = -1
+			i++
+		case isDigit(c) || c == '.' || c == '+' || c == '-':
+			if nParam == 0 {
+				return tokens, fail(i, "unexpected number")
 			}
+			end, ok := scanNumber(raw, i)
+			if !ok {
+				return tokens, fail(i, "bad number %q", raw[i:end])
+			}
+			tokens = append(tokens, token{raw[i:end], false, i})
+			index++
+			comma = -1
+			i = end
 		default:
-			tokens, operand = addOperand(tokens, operand)
+			r, _ := utf8.DecodeRuneInString(raw[i:])
+			return tokens, fail(i, "unexpected character %q", r)
 		}
 	}
-	tokens, operand = addOperand(tokens, operand)
-	return tokens
+	if comma >= 0 {
+		return tokens, fail(comma, "comma at end of path")
+	}
+	return tokens, nil
 }
 
 // toCommands takes a collection of operators and operands and produces
 // Command objects - step 2.
 func toCommands(tokens []token) (commands []Command, err error) {
-	var operands []float64
-	for i := len(tokens) - 1; i >= 0; i-- {
+	commands = make([]Command, 0, len(tokens)/4)
+	for i := 0; i < len(tokens); {
 		t := tokens[i]
-		if t.operator {
-			nParam := allCommands.parameters[strings.ToLower(t.value)]
-			nOperand := len(operands)
-			if nParam == 0 && nOperand == 0 {
-				command := Command{Symbol: t.value}
-				commands = append([]Command{command}, commands...)
-			} else if nParam != 0 && nOperand%nParam == 0 {
-				loopCount := nOperand / nParam
-				for i := 0; i < loopCount; i++ {
-					operator := t.value
-					if operator == "m" && i < loopCount-1 {
-						operator = "l"
-					}
-					if operator == "M" && i < loopCount-1 {
-						operator = "L"
-					}
-					command := Command{operator, reverse(operands[:nParam])}
-					commands = append([]Command{command}, commands...)
-					operands = operands[nParam:]
-				}
-			} else {
-				err := PathParserError{"Incorrect number of parameters for " + t.value}
-				return commands, err
-			}
-		} else {
-			number, err := strconv.ParseFloat(t.value, 64)
+		if !t.operator {
+			return commands, PathParserError{"number before the first command", t.offset, ""}
+		}
+		// The operands of t are the tokens up to the next operator.
+		j := i + 1
+		for j < len(tokens) && !tokens[j].operator {
+			j++
+		}
+		operands := tokens[i+1 : j]
+		i = j
+		nParam, _ := commandParams(t.value[0])
+		nOperand := len(operands)
+		if nParam == 0 && nOperand == 0 {
+			commands = append(commands, Command{Symbol: t.value})
+			continue
+		}
+		if nParam == 0 || nOperand == 0 || nOperand%nParam != 0 {
+			err := PathParserError{"Incorrect number of parameters", t.offset, t.value}
+			return commands, err
+		}
+		// All the parameters of the repeats of the command share one
+		// array.
+		params := make([]float64, nOperand)
+		for k, o := range operands {
+			params[k], err = strconv.ParseFloat(o.value, 64)
 			if err != nil {
-				return commands, err
+				return commands, PathParserError{"number out of range", o.offset, t.value}
+			}
+		}
+		for k := 0; k < nOperand; k += nParam {
+			operator := t.value
+			if operator == "m" && k > 0 {
+				operator = "l"
+			}
+			if operator == "M" && k > 0 {
+				operator = "L"
 			}
-			operands = append(operands, number)
+			commands = append(commands, Command{
+				Symbol:   operator,
+				Params:   params[k : k+nParam : k+nParam],
+				Implicit: k > 0,
+			})
 		}
 	}
 	return commands, nil
@@ -212,7 +308,7 @@ func toCommands(tokens []token) (commands []Command, err error) {
 // all subpaths within the collection - step 3.
 func createSubpaths(commands []Command) (path SVGPath) {
 	var subpath []Command
-	for i, command := range commands {
+	for _, command := range commands {
 		switch strings.ToLower(command.Symbol) {
 		case allCommands.start:
 			if len(subpath) > 0 {
@@ -225,19 +321,22 @@ func createSubpaths(commands []Command) (path SVGPath) {
 			subpath = []Command{}
 		default:
 			subpath = append(subpath, command)
-			if len(commands) == i+1 {
-				path.Subpaths = append(path.Subpaths, Subpath{subpath})
-			}
 		}
 	}
+	if len(subpath) > 0 {
+		path.Subpaths = append(path.Subpaths, Subpath{subpath})
+	}
 	return path
 }
 
 // PathParser takes value of a 'd' attribute and transforms it to collection of
 // subpaths and commands.
 func PathParser(raw string) (path SVGPath, err error) {
-	allCommands = getCommands()
-	commands, err := toCommands(tokenize(raw))
+	tokens, err := tokenize(raw)
+	if err != nil {
+		return path, err
+	}
+	commands, err := toCommands(tokens)
 	if err != nil {
 		return path, err
 	}
@@ -0,0 +1,159 @@
+package kvg
+
+import (
+	"errors"
+	"sync"
+	"testing"
+)
+
+func TestPathParser(t *testing.T) {
+	good := []struct {
+		raw, want string
+	}{
+		{"M1E2,+2e-1L.5.5", "M100,0.2L0.5,0.5"},
+		{"m1,1a1 1 0 00 1 1", "m1,1a1,1,0,0,0,1,1"},
+		{"m1,1a1,1,0,1,0-1-1,2,2,0,11,3,3", "m1,1a1,1,0,1,0-1-1,2,2,0,1,1,3,3"},
+		{"  M 1 , 2 z \n", "M1,2z"},
+		{"", ""},
+		{"M0 0m0 0", "M0,0m0,0"},
+	}
+	for _, test := range good {
+		path, err := PathParser(test.raw)
+		if err != nil {
+			t.Errorf("Error parsing %q: %s", test.raw, err)
+			continue
+		}
+		if got := path.String(); got != test.want {
+			t.Errorf("Parsed %q as %s, expected %s", test.raw, got, test.want)
+		}
+	}
+	bad := []struct {
+		raw     string
+		offset  int
+		command string
+	}{
+		{"L1,1", 0, ""},
+		{"1,1", 0, ""},
+		{"M1,1x2,2", 4, "M"},
+		{"M1,,1", 3, "M"},
+		{"M,1,1", 1, "M"},
+		{"M1,1,L2,2", 4, "M"},
+		{"M1,1c1e,2", 5, "c"},
+		{"M1,1a1,1,0,2,0,1,1", 11, "a"},
+		{"M1,1c1,2,3", 4, "c"},
+		{"M1,1z2", 5, "z"},
+		{"M1,1,", 4, "M"},
+		{"MC0 0 0 0 0 0", 0, "M"},
+		{"M1e700,1", 1, "M"},
+	}
+	for _, test := range bad {
+		_, err := PathParser(test.raw)
+		var perr PathParserError
+		if !errors.As(err, &perr) {
+			t.Errorf("Parsing %q gave %v, expected a PathParserError", test.raw, err)
+			continue
+		}
+		if perr.Offset != test.offset || perr.Command != test.command {
+			t.Errorf("Parsing %q gave error at %d in %q, expected %d in %q: %s",
+				test.raw, perr.Offset, perr.Command, test.offset, test.command, err)
+		}
+	}
+}
+
+// Parse the same paths from several goroutines at once. Run this with
+// -race to check that the parser has no shared state.
+func TestPathParserConcurrent(t *testing.T) {
+	const raw = "M20.5,23.7c2.92,0.68,6.25,0.57,9.23,0.18s5,1,5,2"
+	want, _ := PathParser(raw)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				got, err := PathParser(raw)
+				if err != nil || !got.Compare(want) {
+					t.Errorf("Parsed %s as %s: %v", raw, got, err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func FuzzPathParser(f *testing.F) {
+	for _, seed := range []string{
+		"M20.5,23.7c2.92,0.68,6.25,0.57,9.23,0.18",
+		"M1E2,+2e-1L.5.5z",
+		"m1,1a1 1 0 00 1 1",
+		"M1,1h5v5H0V0s1,2,3,4t5,6q1,2,3,4",
+	} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, raw string) {
+		path, err := PathParser(raw)
+		if err != nil {
+			var perr PathParserError
+			if !errors.As(err, &perr) {
+				t.Fatalf("Parsing %q gave %v, expected a PathParserError", raw, err)
+			}
+			if perr.Offset < 0 || perr.Offset > len(raw) {
+				t.Fatalf("Parsing %q gave offset %d", raw, perr.Offset)
+			}
+			return
+		}
+		again, err := PathParser(path.String())
+		if err != nil {
+			t.Fatalf("Error parsing %q written from %q: %s", path, raw, err)
+		}
+		if !again.Compare(path) {
+			t.Fatalf("%q did not round trip through %q", raw, path)
+		}
+	})
+}
+
+// Get the d attributes of all the paths of the files of the corpus in
+// KANJIVG_DIR, or of the test file if it is not set.
+func corpusPaths(b *testing.B) (ds []string) {
+	corpus, err := OpenEnvCorpus()
+	if err != nil {
+		corpus, err = OpenCorpus(bin() + "/t")
+		if err != nil {
+			b.Fatalf("Error opening test files: %s", err)
+		}
+	}
+	err = corpus.ExamineAllFiles(func(file string, svg SVG) {
+		for _, p := range svg.GetPaths() {
+			ds = append(ds, p.D)
+		}
+	})
+	if err != nil {
+		b.Fatalf("Error reading files: %s", err)
+	}
+	return ds
+}
+
+func BenchmarkPathParser(b *testing.B) {
+	ds := corpusPaths(b)
+	b.ResetTimer()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		for _, d := range ds {
+			PathParser(d)
+		}
+	}
+}
+
+func BenchmarkPathParserParallel(b *testing.B) {
+	ds := corpusPaths(b)
+	b.ResetTimer()
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			for _, d := range ds {
+				PathParser(d)
+			}
+		}
+	})
+}
@@ -0,0 +1,192 @@
+package kvg
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Writing an SVGPath back out as the value of a 'd' attribute.
+
+// Whether the commands of a path are written as absolute or relative.
+type PathMode int
+
+const (
+	// Each command is written as it is.
+	PathAsIs PathMode = iota
+	// All commands are converted to absolute ones, such as C.
+	PathAbsolute
+	// All commands are converted to relative ones, such as c. The
+	// first moveto is written as m, which is the same as M.
+	PathRelative
+)
+
+// The options for writing an SVGPath.
+type PathFormat struct {
+	// The number of digits after the decimal point. Trailing zeros
+	// are removed. If this is negative, each number is written with
+	// as many digits as it needs to be read back exactly.
+	Precision int
+	// Whether to convert the commands to absolute or relative.
+	Mode PathMode
+	// If true, the command symbols and numbers are all separated by
+	// spaces, as in "M 20.5 23.7 c 2.92 0.68". Otherwise the format
+	// of the KanjiVG files is used, with no space after a symbol and
+	// a comma between numbers, except before a minus sign, as in
+	// "M20.5,23.7c2.92-0.68".
+	Spaced bool
+	// If true, the symbols of the commands marked Implicit are left
+	// out, where that gives the same path. Otherwise every command
+	// has its symbol.
+	KeepElision bool
+}
+
+// The format used by SVGPath.String, which is the format of the
+// KanjiVG files.
+var DefaultPathFormat = PathFormat{
+	Precision:   -1,
+	KeepElision: true,
+}
+
+// Make p into the value of a 'd' attribute, using DefaultPathFormat.
+// This gives back the original string for the paths of the KanjiVG
+// files.
+func (p SVGPath) String() string {
+	return DefaultPathFormat.Encode(p)
+}
+
+// Format v with the precision of f.
+func (f PathFormat) number(v float64) string {
+	if f.Precision < 0 {
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	s := strconv.FormatFloat(v, 'f', f.Precision, 64)
+	if strings.Contains(s, ".") {
+		s = strings.TrimRight(s, "0")
+		s = strings.TrimSuffix(s, ".")
+	}
+	if s == "-0" {
+		s = "0"
+	}
+	return s
+}
+
+// Can the symbol of c be left out after a command with symbol prev?
+func elidable(c Command, prev string) bool {
+	switch {
+	case c.Symbol == prev:
+		return strings.ToLower(prev) != "z"
+	case c.Symbol == "l" && prev == "m", c.Symbol == "L" && prev == "M":
+		return true
+	}
+	return false
+}
+
+// Make p into the value of a 'd' attribute in format f.
+func (f PathFormat) Encode(p SVGPath) string {
+	switch f.Mode {
+	case PathAbsolute:
+		p = p.convert(true)
+	case PathRelative:
+		p = p.convert(false)
+	}
+	var b strings.Builder
+	// Whether the last thing written was a number.
+	number := false
+	prev := ""
+	for _, s := range p.Subpaths {
+		for _, c := range s.Commands {
+			if !(f.KeepElision && c.Implicit && elidable(c, prev)) {
+				if f.Spaced && b.Len() > 0 {
+					b.WriteByte(' ')
+				}
+				b.WriteString(c.Symbol)
+				number = false
+			}
+			prev = c.Symbol
+			for _, v := range c.Params {
+				n := f.number(v)
+				switch {
+				case f.Spaced:
+					b.WriteByte(' ')
+				case number && n[0] != '-':
+					b.WriteByte(',')
+				}
+				b.WriteString(n)
+				number = true
+			}
+		}
+	}
+	return b.String()
+}
+
+// The positions within the parameters of a command of the x and y
+// coordinates, which change when converting between absolute and
+// relative. The parameters of an arc are rx, ry, rotation, large arc
+// flag, sweep flag, x, y.
+var coordinates = map[string]struct{ x, y []int }{
+	"m": {[]int{0}, []int{1}},
+	"l": {[]int{0}, []int{1}},
+	"t": {[]int{0}, []int{1}},
+	"h": {[]int{0}, nil},
+	"v": {nil, []int{0}},
+	"c": {[]int{0, 2, 4}, []int{1, 3, 5}},
+	"s": {[]int{0, 2}, []int{1, 3}},
+	"q": {[]int{0, 2}, []int{1, 3}},
+	"a": {[]int{5}, []int{6}},
+	"z": {},
+}
+
+// Make a copy of p with all of the commands converted to absolute
+// ones if abs is true, or relative ones otherwise.
+func (p SVGPath) convert(abs bool) (o SVGPath) {
+	// The current point, and the start of the current subpath.
+	var x, y, startX, startY float64
+	for _, s := range p.Subpaths {
+		var os Subpath
+		for _, c := range s.Commands {
+			lower := strings.ToLower(c.Symbol)
+			coords := coordinates[lower]
+			params := append([]float64{}, c.Params...)
+			// The current point before this command, which is what
+			// relative coordinates are relative to.
+			px, py := x, y
+			if !c.IsAbsolute() {
+				shift(params, coords.x, px)
+				shift(params, coords.y, py)
+			}
+			switch lower {
+			case "z":
+				x, y = startX, startY
+			case "h":
+				x = params[0]
+			case "v":
+				y = params[0]
+			default:
+				x, y = params[len(params)-2], params[len(params)-1]
+			}
+			if lower == "m" {
+				startX, startY = x, y
+			}
+			symbol := strings.ToUpper(c.Symbol)
+			if !abs {
+				symbol = lower
+				shift(params, coords.x, -px)
+				shift(params, coords.y, -py)
+			}
+			os.Commands = append(os.Commands, Command{
+				Symbol:   symbol,
+				Params:   params,
+				Implicit: c.Implicit,
+			})
+		}
+		o.Subpaths = append(o.Subpaths, os)
+	}
+	return o
+}
+
+// Add d to the parameters at the positions in at.
+func shift(params []float64, at []int, d float64) {
+	for _, i := range at {
+		params[i] += d
+	}
+}
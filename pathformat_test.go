@@ -0,0 +1,48 @@
+package kvg
+
+import "testing"
+
+func TestPathFormat(t *testing.T) {
+	svg, err := ReadKanjiFile(bin() + "/t/08475.svg")
+	if err != nil {
+		t.Fatalf("Error reading: %s", err)
+	}
+	for _, p := range svg.GetPaths() {
+		path, err := PathParser(p.D)
+		if err != nil {
+			t.Fatalf("Error parsing %s: %s", p.D, err)
+		}
+		if path.String() != p.D {
+			t.Errorf("Round trip of %s gave %s", p.D, path)
+		}
+	}
+	elided := "M10,10 20,20 30-30c1,2,3,4,5,6 7,8,9,10,11,12zm1,1h5v5"
+	path, err := PathParser(elided)
+	if err != nil {
+		t.Fatalf("Error parsing %s: %s", elided, err)
+	}
+	tests := []struct {
+		format PathFormat
+		want   string
+	}{
+		{DefaultPathFormat, "M10,10,20,20,30-30c1,2,3,4,5,6,7,8,9,10,11,12zm1,1h5v5"},
+		{PathFormat{Precision: -1}, "M10,10L20,20L30-30c1,2,3,4,5,6c7,8,9,10,11,12zm1,1h5v5"},
+		{PathFormat{Precision: -1, Spaced: true, KeepElision: true},
+			"M 10 10 20 20 30 -30 c 1 2 3 4 5 6 7 8 9 10 11 12 z m 1 1 h 5 v 5"},
+		{PathFormat{Precision: -1, Mode: PathAbsolute},
+			"M10,10L20,20L30-30C31-28,33-26,35-24C42-16,44-14,46-12ZM11,11H16V16"},
+		{PathFormat{Precision: -1, Mode: PathRelative},
+			"m10,10l10,10l10-50c1,2,3,4,5,6c7,8,9,10,11,12zm1,1h5v5"},
+	}
+	for _, test := range tests {
+		got := test.format.Encode(path)
+		if got != test.want {
+			t.Errorf("Encoded %s as\n%s, expected\n%s", elided, got, test.want)
+		}
+	}
+	path, _ = PathParser("M0.126,1c0.3333,1,2,3,4,-0.0001")
+	got := PathFormat{Precision: 2}.Encode(path)
+	if got != "M0.13,1c0.33,1,2,3,4,0" {
+		t.Errorf("Bad precision: %s", got)
+	}
+}
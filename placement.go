@@ -0,0 +1,277 @@
+package kvg
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// Placing the stroke-number labels.
+//
+// Each label is put as near as it can be to the start of its stroke,
+// keeping clear of all of the strokes and of the labels already
+// placed, and inside the viewBox. The places tried are on rings
+// around the start of the stroke, and the nearest place which is
+// clear is used, with places ahead of the stroke, where it goes after
+// it starts, counting as further away.
+
+// The sizes used to place labels. The distances are in the units of
+// the SVG.
+type LabelPlacement struct {
+	// The font size of the labels, which is 8 in the KanjiVG files.
+	FontSize float64
+	// The least distance from a label to the middle of a stroke.
+	// The KanjiVG strokes are 3 wide, so this must be more than 1.5
+	// for the label not to touch the stroke.
+	StrokeGap float64
+	// The least distance between two labels.
+	LabelGap float64
+	// The furthest a label may be from the start of its stroke.
+	MaxDistance float64
+}
+
+// The sizes used for the KanjiVG files.
+var DefaultLabelPlacement = LabelPlacement{
+	FontSize:    8,
+	StrokeGap:   2.5,
+	LabelGap:    1,
+	MaxDistance: 16,
+}
+
+const (
+	// The width of a digit and the height of the digits above the
+	// baseline, as fractions of the font size.
+	digitWidth  = 0.5
+	digitHeight = 0.72
+	// The distance between the rings of places tried, and the angle
+	// in degrees between places on a ring.
+	placeStep  = 1
+	placeAngle = 15
+	// How much further away a place directly ahead of a stroke
+	// counts as.
+	aheadPenalty = 4
+	// How much nearer the start of another stroke must be for a
+	// label to count as nearer to it, so that strokes which start at
+	// the same place do not count.
+	nearerMargin = 1
+)
+
+// Get the box of the text s written at p, which is the left end of
+// the baseline as in the transforms of the labels.
+func (lp LabelPlacement) box(s string, p Point) Rect {
+	w := digitWidth * lp.FontSize * float64(len(s))
+	h := digitHeight * lp.FontSize
+	return Rect{Point{p.X, p.Y - h}, Point{p.X + w, p.Y}}
+}
+
+// Get the box of the label t on the page, from its position, its
+// number and the font size of lp.
+func (lp LabelPlacement) LabelBox(t *Text) Rect {
+	return lp.box(string(t.Content), t.Position())
+}
+
+// Get the distance between r and the point p, which is zero if p is
+// inside r.
+func rectPointDistance(r Rect, p Point) float64 {
+	dx := math.Max(0, math.Max(r.Min.X-p.X, p.X-r.Max.X))
+	dy := math.Max(0, math.Max(r.Min.Y-p.Y, p.Y-r.Max.Y))
+	return math.Hypot(dx, dy)
+}
+
+// Get the distance between the rectangles r and o, which is zero if
+// they overlap.
+func rectDistance(r, o Rect) float64 {
+	dx := math.Max(0, math.Max(r.Min.X-o.Max.X, o.Min.X-r.Max.X))
+	dy := math.Max(0, math.Max(r.Min.Y-o.Max.Y, o.Min.Y-r.Max.Y))
+	return math.Hypot(dx, dy)
+}
+
+// Get the distance between r and the polyline l, which is zero if l
+// goes through r.
+func (l polyline) rectDistance(r Rect) float64 {
+	if len(l.points) == 0 {
+		return math.Inf(1)
+	}
+	corners := []Point{r.Min, {r.Max.X, r.Min.Y}, r.Max, {r.Min.X, r.Max.Y}}
+	d := math.Inf(1)
+	for i, p := range l.points {
+		d = math.Min(d, rectPointDistance(r, p))
+		if i == 0 {
+			continue
+		}
+		for j := range corners {
+			if _, _, ok := crossing(l.points[i-1], p, corners[j], corners[(j+1)%4]); ok {
+				return 0
+			}
+		}
+	}
+	for _, c := range corners {
+		_, cd, _ := l.nearest(c)
+		d = math.Min(d, cd)
+	}
+	return d
+}
+
+// The strokes and labels which a label must keep clear of.
+type labelPlacer struct {
+	lp    LabelPlacement
+	lines []polyline
+	// The boxes of the labels already placed.
+	boxes []Rect
+	view  Rect
+}
+
+func (svg *SVG) newLabelPlacer(lp LabelPlacement) (pl *labelPlacer, err error) {
+	pl = &labelPlacer{lp: lp}
+	pl.view, err = svg.ViewBoxRect()
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range svg.GetPaths() {
+		path, err := p.Parse()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", p.ID, err)
+		}
+		pl.lines = append(pl.lines, newPolyline(path))
+	}
+	return pl, nil
+}
+
+// Get how far inside the gaps the box b is, which is zero or less if b
+// is clear of every stroke and label and inside the viewBox.
+func (pl *labelPlacer) clash(b Rect) (c float64) {
+	c = math.Inf(-1)
+	for _, l := range pl.lines {
+		c = math.Max(c, pl.lp.StrokeGap-l.rectDistance(b))
+	}
+	for _, o := range pl.boxes {
+		c = math.Max(c, pl.lp.LabelGap-rectDistance(b, o))
+	}
+	if !pl.view.Contains(b) {
+		c = math.Inf(1)
+	}
+	return c
+}
+
+// Find the stroke other than i whose start is nearer to the box b than
+// the start of stroke i, with the distances of b from both starts. If
+// there is none, j is -1.
+func (pl *labelPlacer) nearerOther(i int, b Rect) (j int, d, own float64) {
+	if len(pl.lines[i].points) == 0 {
+		return -1, 0, 0
+	}
+	own = rectPointDistance(b, pl.lines[i].points[0])
+	for j, l := range pl.lines {
+		if j == i || len(l.points) == 0 {
+			continue
+		}
+		if d := rectPointDistance(b, l.points[0]); d < own-nearerMargin {
+			return j, d, own
+		}
+	}
+	return -1, 0, own
+}
+
+// Find the place for the label s of stroke i. A place nearer to the
+// start of another stroke is not clear. If there is no clear
+// place within MaxDistance, the place which clashes least is used.
+func (pl *labelPlacer) place(i int, s string) (at Point) {
+	l := pl.lines[i]
+	if len(l.points) == 0 {
+		return at
+	}
+	start := l.points[0]
+	var ahead Point
+	for _, p := range l.points[1:] {
+		if p != start {
+			ahead = p.sub(start).unit()
+			break
+		}
+	}
+	empty := pl.lp.box(s, Point{})
+	size := empty.Max.sub(empty.Min)
+	bestCost, leastClash := math.Inf(1), math.Inf(1)
+	var clear bool
+	for r := 0.0; r <= pl.lp.MaxDistance; r += placeStep {
+		for a := 0; a < 360; a += placeAngle {
+			sin, cos := math.Sincos(float64(a) * math.Pi / 180)
+			u := Point{cos, sin}
+			// The place is the bottom left of a box centred on
+			// the point r from the start.
+			centre := start.add(u.scale(r))
+			p := Point{centre.X - size.X/2, centre.Y + size.Y/2}
+			b := pl.lp.box(s, p)
+			c := pl.clash(b)
+			if j, _, _ := pl.nearerOther(i, b); j >= 0 {
+				c = math.Max(c, nearerMargin)
+			}
+			cost := r + aheadPenalty*(1+u.X*ahead.X+u.Y*ahead.Y)/2
+			switch {
+			case c <= 0 && (!clear || cost < bestCost):
+				at, bestCost, clear = p, cost, true
+			case !clear && c < leastClash:
+				at, leastClash = p, c
+			}
+		}
+	}
+	pl.boxes = append(pl.boxes, pl.lp.box(s, at))
+	return at
+}
+
+// Make the stroke-number labels of svg again from nothing, with one
+// label for each stroke placed as described above. If svg has no
+// group of labels, one is added with the usual KanjiVG style.
+func (svg *SVG) PlaceLabels(lp LabelPlacement) (err error) {
+	pl, err := svg.newLabelPlacer(lp)
+	if err != nil {
+		return err
+	}
+	if len(svg.Groups) < 2 {
+		_, tail := svg.Base()
+		svg.Groups = append(svg.Groups, Group{
+			ID:    "kvg:StrokeNumbers_" + tail,
+			Style: "font-size:8;fill:#808080",
+		})
+	}
+	var labels []Child
+	for i := range pl.lines {
+		c := Child{IsText: true}
+		c.Text.SetNumber(i + 1)
+		at := pl.place(i, string(c.Text.Content))
+		c.Text.Transform = labelTransform(at.X, at.Y)
+		labels = append(labels, c)
+	}
+	svg.Groups[1].Children = labels
+	svg.Link()
+	return nil
+}
+
+// Place again the labels move of svg, keeping clear of the other
+// labels, which stay where they are. Each of move must be one of the
+// labels of svg whose number is that of a stroke. The transform of a
+// label which is moved is replaced if it could not be read, and a label
+// which is not moved and whose transform could not be read is not kept
+// clear of, since its place is not known.
+func (svg *SVG) MoveLabels(lp LabelPlacement, move []*Text) (err error) {
+	pl, err := svg.newLabelPlacer(lp)
+	if err != nil {
+		return err
+	}
+	moving := map[*Text]bool{}
+	for _, t := range move {
+		if svg.PathOf(t) == nil {
+			return fmt.Errorf("label %q has no stroke", t.Content)
+		}
+		moving[t] = true
+	}
+	for _, t := range svg.Labels() {
+		if !moving[t] && t.Transform.Valid() {
+			pl.boxes = append(pl.boxes, lp.LabelBox(t))
+		}
+	}
+	for _, t := range move {
+		n, _ := t.Number()
+		t.SetPosition(pl.place(n-1, strconv.Itoa(n)))
+	}
+	return nil
+}
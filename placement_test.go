@@ -0,0 +1,82 @@
+package kvg
+
+import (
+	"strings"
+	"testing"
+)
+
+// Check that the labels check of svg are clear of the strokes and of
+// the other labels.
+func checkPlaced(t *testing.T, svg *SVG, check []*Text) {
+	t.Helper()
+	lp := DefaultLabelPlacement
+	labels := svg.Labels()
+	for _, l := range check {
+		pl, err := svg.newLabelPlacer(lp)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, o := range labels {
+			if o != l {
+				pl.boxes = append(pl.boxes, lp.LabelBox(o))
+			}
+		}
+		if c := pl.clash(lp.LabelBox(l)); c > 0 {
+			t.Errorf("Label %s at %v is not clear by %g", l.Content, l.Position(), c)
+		}
+		start, _, _ := svg.PathOf(l).PointAt(0)
+		if d := rectPointDistance(lp.LabelBox(l), start); d > lp.MaxDistance {
+			t.Errorf("Label %s is %g from its stroke", l.Content, d)
+		}
+	}
+}
+
+func TestPlaceLabels(t *testing.T) {
+	svg, err := ReadKanjiFile(bin() + "/t/08475.svg")
+	if err != nil {
+		t.Fatalf("Error reading: %s", err)
+	}
+	svg.Groups[1].Children = svg.Groups[1].Children[:3]
+	err = svg.PlaceLabels(DefaultLabelPlacement)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkNumbers(t, &svg, 12)
+	checkPlaced(t, &svg, svg.Labels())
+
+	// Without a group of labels, one is made.
+	svg.Groups = svg.Groups[:1]
+	err = svg.PlaceLabels(DefaultLabelPlacement)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkNumbers(t, &svg, 12)
+	out := string(svg.MakeXML())
+	if !strings.Contains(out, `<g id="kvg:StrokeNumbers_08475" style="font-size:8;fill:#808080">`) {
+		t.Errorf("No group of labels in output")
+	}
+}
+
+func TestMoveLabels(t *testing.T) {
+	svg, err := ReadKanjiFile(bin() + "/t/08475.svg")
+	if err != nil {
+		t.Fatalf("Error reading: %s", err)
+	}
+	labels := svg.Labels()
+	others := transforms(&svg)[1:]
+	labels[0].SetPosition(Point{100, 100})
+	err = svg.MoveLabels(DefaultLabelPlacement, labels[:1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkPlaced(t, &svg, labels[:1])
+	for i, tr := range transforms(&svg)[1:] {
+		if tr != others[i] {
+			t.Errorf("Label %d moved from %s to %s", i+2, others[i], tr)
+		}
+	}
+	labels[1].SetNumber(13)
+	if svg.MoveLabels(DefaultLabelPlacement, labels[1:2]) == nil {
+		t.Errorf("Moved a label with no stroke")
+	}
+}
@@ -0,0 +1,74 @@
+package kvg
+
+import "strings"
+
+// The stroke types of the kvg:type attribute.
+//
+// The kvg:type of a path is one of the characters of the CJK Strokes
+// block, such as ㇐ for a horizontal stroke, sometimes followed by a
+// letter for a variant, such as ㇑a, and sometimes two types separated
+// by a slash where either may be meant, such as ㇔/㇀.
+
+// A stroke type of the CJK Strokes block.
+type strokeType struct {
+	// The letters of the Unicode name, such as "HZ" for 横折.
+	name string
+	// The direction in which the stroke sets off, and the direction
+	// from its start to its end.
+	start, chord Direction
+}
+
+// The stroke types, with the directions in which they are drawn.
+// ㇣ (Q), a circle, has no direction and is left out.
+var strokeTypes = map[rune]strokeType{
+	'㇀': {"T", -40, -40},
+	'㇁': {"WG", 90, 90},
+	'㇂': {"XG", 60, 60},
+	'㇃': {"BXG", 45, 20},
+	'㇄': {"SW", 90, 45},
+	'㇅': {"HZZ", 0, 45},
+	'㇆': {"HZG", 0, 70},
+	'㇇': {"HP", 0, 110},
+	'㇈': {"HZWG", 0, 60},
+	'㇉': {"SZWG", 90, 70},
+	'㇊': {"HZT", 0, 90},
+	'㇋': {"HZZP", 0, 100},
+	'㇌': {"HPWG", 0, 90},
+	'㇍': {"HZW", 0, 45},
+	'㇎': {"HZZZ", 0, 90},
+	'㇏': {"N", 45, 40},
+	'㇐': {"H", 0, 0},
+	'㇑': {"S", 90, 90},
+	'㇒': {"P", 120, 130},
+	'㇓': {"SP", 90, 110},
+	'㇔': {"D", 50, 50},
+	'㇕': {"HZ", 0, 60},
+	'㇖': {"HG", 0, 10},
+	'㇗': {"SZ", 90, 45},
+	'㇘': {"SWZ", 90, 45},
+	'㇙': {"ST", 90, 80},
+	'㇚': {"SG", 90, 95},
+	'㇛': {"PD", 120, 90},
+	'㇜': {"PZ", 120, 90},
+	'㇝': {"TN", 20, 30},
+	'㇞': {"SZZ", 90, 45},
+	'㇟': {"SWG", 90, 40},
+	'㇠': {"HXWG", 0, 60},
+	'㇡': {"HZZZG", 0, 80},
+	'㇢': {"PG", 110, 90},
+}
+
+// Get the CJK stroke characters of a kvg:type value, without the
+// variant letters, so "㇔/㇀" gives ㇔ and ㇀, and "㇑a" gives ㇑. Parts
+// which are not known stroke types are left out.
+func StrokeTypes(kvgType string) (types []rune) {
+	for _, alt := range strings.Split(kvgType, "/") {
+		for _, r := range alt {
+			if _, ok := strokeTypes[r]; ok {
+				types = append(types, r)
+				break
+			}
+		}
+	}
+	return types
+}
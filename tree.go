@@ -0,0 +1,277 @@
+package kvg
+
+// Navigation of the tree of groups, paths and texts.
+//
+// Each Child records the group it is in, and each Path, Text and Group
+// records the Child which holds it. These links are set by Link, which
+// is called by ParseKanji, RenumberXML, SetBase and the editing
+// methods. Because the links are pointers into Children slices, they
+// go out of date if a Children slice is changed by hand. The methods
+// below check the links before using them, and repair the link of an
+// element if the slice holding it has been reallocated, but after
+// editing the Children of a group by hand it is best to call Link on
+// the SVG again.
+
+// Set the links from each element of svg to its parent group.
+func (svg *SVG) Link() {
+	for i := range svg.Groups {
+		svg.Groups[i].node = nil
+		svg.Groups[i].Link()
+	}
+}
+
+// Set the links from each element under g to its parent group. This
+// does not change the link from g to its own parent.
+func (g *Group) Link() {
+	for i := range g.Children {
+		c := &g.Children[i]
+		c.parent = g
+		switch {
+		case c.IsGroup:
+			c.Group.node = c
+			c.Group.Link()
+		case c.IsText:
+			c.Text.node = c
+		case c.IsPath():
+			c.Path.node = c
+		}
+	}
+}
+
+// Is c a path, rather than a group or a text?
+func (c *Child) IsPath() bool {
+	return !c.IsGroup && !c.IsText
+}
+
+// Find the child of the group which node was linked to which matches
+// the element, or nil if there is none.
+func locate(node *Child, match func(c *Child) bool) *Child {
+	if node == nil || node.parent == nil {
+		return nil
+	}
+	g := node.parent
+	for i := range g.Children {
+		c := &g.Children[i]
+		if match(c) {
+			return c
+		}
+	}
+	return nil
+}
+
+// The child which holds p, or nil.
+func (p *Path) child() *Child {
+	c := locate(p.node, func(c *Child) bool {
+		return c.IsPath() && &c.Path == p
+	})
+	if c != nil {
+		p.node = c
+	}
+	return c
+}
+
+// The child which holds t, or nil.
+func (t *Text) child() *Child {
+	c := locate(t.node, func(c *Child) bool {
+		return c.IsText && &c.Text == t
+	})
+	if c != nil {
+		t.node = c
+	}
+	return c
+}
+
+// Is a the same group as b? They are the same if they are held by the
+// same child in the tree, so an out-of-date copy of a group is the same
+// as the group, as far as its link can be repaired.
+func sameGroup(a, b *Group) bool {
+	if a == b {
+		return true
+	}
+	c := a.child()
+	return c != nil && c == b.child()
+}
+
+// Could a be an out-of-date copy of b, made by the reallocation of the
+// Children slice holding b? Such a copy still shares its own Children
+// with b. This is only used to repair the link of a copy.
+func copyOf(a, b *Group) bool {
+	return len(a.Children) > 0 && len(b.Children) > 0 &&
+		&a.Children[0] == &b.Children[0]
+}
+
+// The child which holds g, or nil if g is a top-level group. If g is
+// an out-of-date copy of a group, this is the child holding the
+// current one.
+func (g *Group) child() *Child {
+	c := locate(g.node, func(c *Child) bool {
+		return c.IsGroup && (&c.Group == g || copyOf(g, &c.Group))
+	})
+	if c != nil {
+		g.node = c
+	}
+	return c
+}
+
+// The position of c within the Children of its parent, or -1 if c is
+// not in a group.
+func (c *Child) IndexInParent() int {
+	g := c.parent
+	if g == nil {
+		return -1
+	}
+	for i := range g.Children {
+		if &g.Children[i] == c {
+			return i
+		}
+	}
+	return -1
+}
+
+// The group which contains c, or nil.
+func (c *Child) Parent() *Group {
+	if c.IndexInParent() < 0 {
+		return nil
+	}
+	if gc := c.parent.child(); gc != nil {
+		c.parent = &gc.Group
+	}
+	return c.parent
+}
+
+// The groups which contain c, starting with its parent and ending
+// with the top-level group. This is the same order as the location
+// returned by FindElement.
+func (c *Child) Ancestors() (groups []*Group) {
+	for g := c.Parent(); g != nil; g = g.Parent() {
+		groups = append(groups, g)
+	}
+	return groups
+}
+
+// The number of groups which contain c.
+func (c *Child) Depth() int {
+	return len(c.Ancestors())
+}
+
+// The other children of the parent of c, in order.
+func (c *Child) Siblings() (siblings []*Child) {
+	g := c.Parent()
+	if g == nil {
+		return nil
+	}
+	for i := range g.Children {
+		s := &g.Children[i]
+		if s != c {
+			siblings = append(siblings, s)
+		}
+	}
+	return siblings
+}
+
+// The group which contains p, or nil.
+func (p *Path) Parent() *Group {
+	return p.child().parentOrNil()
+}
+
+// See Child.Ancestors.
+func (p *Path) Ancestors() []*Group {
+	return p.child().ancestorsOrNil()
+}
+
+// See Child.Siblings.
+func (p *Path) Siblings() []*Child {
+	return p.child().siblingsOrNil()
+}
+
+// See Child.Depth.
+func (p *Path) Depth() int {
+	return len(p.Ancestors())
+}
+
+// The position of p within the Children of its parent, or -1.
+func (p *Path) IndexInParent() int {
+	return p.child().indexOrNone()
+}
+
+// The group which contains t, or nil.
+func (t *Text) Parent() *Group {
+	return t.child().parentOrNil()
+}
+
+// See Child.Ancestors.
+func (t *Text) Ancestors() []*Group {
+	return t.child().ancestorsOrNil()
+}
+
+// See Child.Siblings.
+func (t *Text) Siblings() []*Child {
+	return t.child().siblingsOrNil()
+}
+
+// See Child.Depth.
+func (t *Text) Depth() int {
+	return len(t.Ancestors())
+}
+
+// The position of t within the Children of its parent, or -1.
+func (t *Text) IndexInParent() int {
+	return t.child().indexOrNone()
+}
+
+// The group which contains g, or nil if g is a top-level group of the
+// SVG.
+func (g *Group) Parent() *Group {
+	return g.child().parentOrNil()
+}
+
+// See Child.Ancestors.
+func (g *Group) Ancestors() []*Group {
+	return g.child().ancestorsOrNil()
+}
+
+// See Child.Siblings.
+func (g *Group) Siblings() []*Child {
+	return g.child().siblingsOrNil()
+}
+
+// See Child.Depth. The top-level groups of the SVG have depth zero.
+func (g *Group) Depth() int {
+	return len(g.Ancestors())
+}
+
+// The position of g within the Children of its parent, or -1 for a
+// top-level group.
+func (g *Group) IndexInParent() int {
+	return g.child().indexOrNone()
+}
+
+// The following allow the methods above to work with a nil child.
+
+func (c *Child) parentOrNil() *Group {
+	if c == nil {
+		return nil
+	}
+	return c.Parent()
+}
+
+func (c *Child) ancestorsOrNil() []*Group {
+	if c == nil {
+		return nil
+	}
+	return c.Ancestors()
+}
+
+func (c *Child) siblingsOrNil() []*Child {
+	if c == nil {
+		return nil
+	}
+	return c.Siblings()
+}
+
+func (c *Child) indexOrNone() int {
+	if c == nil {
+		return -1
+	}
+	return c.IndexInParent()
+}
@@ -0,0 +1,77 @@
+package kvg
+
+import "testing"
+
+func TestTree(t *testing.T) {
+	svg, err := ReadKanjiFile(bin() + "/t/08475.svg")
+	if err != nil {
+		t.Fatalf("Error reading: %s", err)
+	}
+	paths := svg.GetPaths()
+	s5 := paths[4]
+	if s5.Parent() == nil || s5.Parent().ID != "kvg:08475-g4" {
+		t.Fatalf("Wrong parent for %s", s5.ID)
+	}
+	if s5.IndexInParent() != 1 || s5.Depth() != 5 {
+		t.Errorf("Wrong index %d or depth %d", s5.IndexInParent(), s5.Depth())
+	}
+	ids := []string{"kvg:08475-g4", "kvg:08475-g3", "kvg:08475-g2", "kvg:08475", "kvg:StrokePaths_08475"}
+	for i, g := range s5.Ancestors() {
+		if g.ID != ids[i] {
+			t.Errorf("Ancestor %d is %s not %s", i, g.ID, ids[i])
+		}
+	}
+	sib := s5.Siblings()
+	if len(sib) != 1 || sib[0].Path.ID != "kvg:08475-s4" {
+		t.Errorf("Wrong siblings")
+	}
+	if svg.Groups[0].Parent() != nil || svg.Groups[0].IndexInParent() != -1 {
+		t.Errorf("Top-level group has a parent")
+	}
+	labels := &svg.Groups[1]
+	if labels.Children[2].Text.Parent() != labels {
+		t.Errorf("Wrong parent for label")
+	}
+	// Reallocate the children of g4 and g3 by hand, and check that
+	// the links are still correct.
+	g3 := s5.Ancestors()[1]
+	g4 := &g3.Children[0].Group
+	g4.Children = append(g4.Children, Child{})
+	g3.Children = append(g3.Children, Child{})
+	paths = g3.GetPaths()
+	p := paths[1]
+	if p.ID != "kvg:08475-s5" || p.IndexInParent() != 1 {
+		t.Fatalf("Wrong path %s or index %d", p.ID, p.IndexInParent())
+	}
+	if p.Parent() != &g3.Children[0].Group {
+		t.Errorf("Parent is out of date after reallocation")
+	}
+	if p.Parent().Parent() != g3 {
+		t.Errorf("Grandparent is wrong after reallocation")
+	}
+}
+
+func TestSameGroup(t *testing.T) {
+	svg, err := ReadKanjiFile(bin() + "/t/08475.svg")
+	if err != nil {
+		t.Fatalf("Error reading: %s", err)
+	}
+	svg.Link()
+	g := svg.GetPaths()[4].Parent()
+	if !sameGroup(g, g) {
+		t.Errorf("Group is not the same as itself")
+	}
+	copied := *g
+	if !sameGroup(&copied, g) {
+		t.Errorf("Copy of a group is not the same as the group")
+	}
+	// A group which merely shares the children of another group is
+	// not held by the same child, so it is not the same group.
+	fake := Group{Children: g.Children}
+	if sameGroup(&fake, g) {
+		t.Errorf("Group sharing children is the same group")
+	}
+	if sameGroup(g, g.Parent()) {
+		t.Errorf("Group is the same as its parent")
+	}
+}
@@ -0,0 +1,195 @@
+package kvg
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// The values of the kvg:position, kvg:radical and kvg:part attributes
+// of groups. These are strings, so that they are written to the files
+// as they are, but each has its own type, so that a switch on one of
+// them can only use the constants of that type.
+
+// The value of kvg:position, which gives the place of a group within
+// the group containing it.
+type Position string
+
+// The known values of kvg:position. The names of the enclosing
+// positions are the Japanese names of the radical shapes. A group
+// with position "nyo", "tare" or "kamae" is usually followed by a
+// group with the matching "c" position, which is the part enclosed by
+// it.
+const (
+	PositionLeft   Position = "left"
+	PositionRight  Position = "right"
+	PositionTop    Position = "top"
+	PositionBottom Position = "bottom"
+	// An enclosure from the left and below, like 辶.
+	PositionNyo  Position = "nyo"
+	PositionNyoc Position = "nyoc"
+	// An enclosure from the left and above, like 广.
+	PositionTare  Position = "tare"
+	PositionTarec Position = "tarec"
+	// An enclosure from both sides or all around, like 門 or 囗.
+	PositionKamae  Position = "kamae"
+	PositionKamaec Position = "kamaec"
+	// The two halves of an enclosure which is split by the enclosed
+	// part, like 衣 in 衷.
+	PositionKamae1 Position = "kamae1"
+	PositionKamae2 Position = "kamae2"
+	// An enclosure from above, like 冂 in 岡.
+	PositionSurroundAboveA Position = "⿵A"
+	// An enclosure from below, like 凵 in 凶. The second form is used
+	// in 輿 and 鼎.
+	PositionSurroundBelow  Position = "⿶"
+	PositionSurroundBelow2 Position = "⿶2"
+)
+
+var positions = map[Position]bool{
+	PositionLeft: true, PositionRight: true, PositionTop: true,
+	PositionBottom: true, PositionNyo: true, PositionNyoc: true,
+	PositionTare: true, PositionTarec: true, PositionKamae: true,
+	PositionKamaec: true, PositionKamae1: true, PositionKamae2: true,
+	PositionSurroundAboveA: true, PositionSurroundBelow: true,
+	PositionSurroundBelow2: true,
+}
+
+// Convert s into a Position. The error is ErrUnknownValue if s is not
+// one of the known values. The empty string, meaning no position, is
+// not an error.
+func ParsePosition(s string) (p Position, err error) {
+	p = Position(s)
+	if !p.Valid() {
+		return p, &Error{Op: "ParsePosition", Name: s, Kind: ErrUnknownValue}
+	}
+	return p, nil
+}
+
+// Is p empty or one of the known values?
+func (p Position) Valid() bool {
+	return len(p) == 0 || positions[p]
+}
+
+func (p Position) String() string {
+	return string(p)
+}
+
+// The value of kvg:radical, which says which dictionaries consider
+// the group to be the radical of the kanji. The struct Radical holds
+// the groups of each type.
+type RadicalType string
+
+// The known values of kvg:radical.
+const (
+	// The radical in most dictionaries.
+	RadicalGeneral RadicalType = "general"
+	// The radical in Nelson's dictionary, where it differs from the
+	// traditional one.
+	RadicalNelson RadicalType = "nelson"
+	// The traditional Kangxi radical, where it differs from the
+	// general one.
+	RadicalTradit RadicalType = "tradit"
+	// The radical of the JIS standard.
+	RadicalJIS RadicalType = "jis"
+)
+
+var radicalTypes = map[RadicalType]bool{
+	RadicalGeneral: true, RadicalNelson: true, RadicalTradit: true,
+	RadicalJIS: true,
+}
+
+// Convert s into a RadicalType. The error is ErrUnknownValue if s is
+// not one of the known values. The empty string is not an error.
+func ParseRadicalType(s string) (r RadicalType, err error) {
+	r = RadicalType(s)
+	if !r.Valid() {
+		return r, &Error{Op: "ParseRadicalType", Name: s, Kind: ErrUnknownValue}
+	}
+	return r, nil
+}
+
+// Is r empty or one of the known values?
+func (r RadicalType) Valid() bool {
+	return len(r) == 0 || radicalTypes[r]
+}
+
+func (r RadicalType) String() string {
+	return string(r)
+}
+
+// The value of kvg:part. When the strokes of an element are not all
+// together, for example 衣 in 衷, each group of strokes of the element
+// has the same kvg:element, and kvg:part numbers the groups from one.
+type Part string
+
+// Convert s into a Part. The error is ErrUnknownValue unless s is a
+// positive whole number or empty.
+func ParsePart(s string) (p Part, err error) {
+	p = Part(s)
+	if !p.Valid() {
+		return p, &Error{Op: "ParsePart", Name: s, Kind: ErrUnknownValue}
+	}
+	return p, nil
+}
+
+// Get the number of part p, or zero if p is empty or not a number.
+func (p Part) Number() int {
+	n, err := strconv.Atoi(string(p))
+	if err != nil || n < 1 || string(p) != strconv.Itoa(n) {
+		return 0
+	}
+	return n
+}
+
+// Is p empty or a positive whole number?
+func (p Part) Valid() bool {
+	return len(p) == 0 || p.Number() > 0
+}
+
+func (p Part) String() string {
+	return string(p)
+}
+
+// A group attribute with an unknown value.
+type ValueError struct {
+	// The file the SVG was read from.
+	File string
+	// The ID of the group.
+	ID string
+	// The attribute, such as "kvg:position".
+	Attr  string
+	Value string
+}
+
+func (e *ValueError) Error() string {
+	return fmt.Sprintf("%s: %s: unknown %s value '%s'", e.File, e.ID, e.Attr, e.Value)
+}
+
+// Unwrap returns ErrUnknownValue.
+func (e *ValueError) Unwrap() error {
+	return ErrUnknownValue
+}
+
+// Check the kvg:position, kvg:radical and kvg:part values of all the
+// groups in svg, and return an error for each unknown value. The file
+// name is only used in the errors.
+func (svg *SVG) CheckValues(file string) (errs []*ValueError) {
+	for i := range svg.Groups {
+		for _, g := range svg.Groups[i].GetGroups() {
+			bad := func(attr, value string) {
+				errs = append(errs, &ValueError{File: file, ID: g.ID,
+					Attr: attr, Value: value})
+			}
+			if !g.Position.Valid() {
+				bad("kvg:position", string(g.Position))
+			}
+			if !g.Radical.Valid() {
+				bad("kvg:radical", string(g.Radical))
+			}
+			if !g.Part.Valid() {
+				bad("kvg:part", string(g.Part))
+			}
+		}
+	}
+	return errs
+}
@@ -0,0 +1,47 @@
+package kvg
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestValues(t *testing.T) {
+	p, err := ParsePosition("⿵A")
+	if err != nil || p != PositionSurroundAboveA {
+		t.Errorf("Bad parse of ⿵A: %s %v", p, err)
+	}
+	_, err = ParsePosition("middle")
+	if !errors.Is(err, ErrUnknownValue) {
+		t.Errorf("Expected ErrUnknownValue, got %v", err)
+	}
+	r, err := ParseRadicalType("tradit")
+	if err != nil || r != RadicalTradit || r.String() != "tradit" {
+		t.Errorf("Bad parse of tradit: %s %v", r, err)
+	}
+	for _, s := range []string{"0", "01", "a", "-1"} {
+		if Part(s).Valid() {
+			t.Errorf("Part %s is valid", s)
+		}
+	}
+	if Part("2").Number() != 2 || !Part("").Valid() {
+		t.Errorf("Bad part numbers")
+	}
+	svg, err := ReadKanjiFile(bin() + "/t/08475.svg")
+	if err != nil {
+		t.Fatalf("Error reading: %s", err)
+	}
+	if errs := svg.CheckValues("08475.svg"); len(errs) != 0 {
+		t.Errorf("Unexpected errors %v", errs)
+	}
+	g4 := svg.GetPaths()[4].Parent()
+	g4.Position = "leftish"
+	g4.Parent().Radical = "nelsen"
+	errs := svg.CheckValues("08475.svg")
+	if len(errs) != 2 {
+		t.Fatalf("Expected 2 errors, got %v", errs)
+	}
+	msg := "08475.svg: kvg:08475-g4: unknown kvg:position value 'leftish'"
+	if errs[0].Error() != msg || !errors.Is(errs[1], ErrUnknownValue) {
+		t.Errorf("Bad error %s", errs[0])
+	}
+}
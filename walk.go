@@ -0,0 +1,236 @@
+package kvg
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Options for Corpus.Walk.
+type WalkOptions struct {
+	// The number of files processed at once. If this is zero or
+	// less, the number of CPUs is used.
+	Workers int
+	// If true, the output of each file is written to Output in
+	// alphabetical order of file name, so that it does not depend on
+	// the order in which the goroutines finish. Otherwise each
+	// file's output is written as soon as its WalkFunc returns.
+	Ordered bool
+	// Where the output of each file goes. If this is nil, os.Stdout
+	// is used.
+	Output io.Writer
+}
+
+// A file being examined by Corpus.Walk.
+type WalkFile struct {
+	// The name of the file, as returned by Corpus.Files.
+	Name string
+	// The position of the file in the list of all files, starting
+	// from zero.
+	Index int
+	// The contents of the file.
+	Contents []byte
+	// The parsed contents of the file.
+	SVG SVG
+	// Anything written here is copied to WalkOptions.Output after
+	// the WalkFunc returns.
+	Out bytes.Buffer
+}
+
+// The function called by Corpus.Walk for each file. It is called from
+// several goroutines at once, so it must be safe for concurrent use.
+type WalkFunc func(f *WalkFile) error
+
+// An error which occurred while examining one file.
+type FileError struct {
+	File string
+	Err  error
+}
+
+func (e *FileError) Error() string {
+	return e.File + ": " + e.Err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (e *FileError) Unwrap() error {
+	return e.Err
+}
+
+// The errors from all the files which failed during Corpus.Walk,
+// sorted by file name.
+type WalkErrors []*FileError
+
+func (e WalkErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, fe := range e {
+		msgs[i] = fe.Error()
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// Unwrap returns the errors of the individual files.
+func (e WalkErrors) Unwrap() []error {
+	errs := make([]error, len(e))
+	for i, fe := range e {
+		errs[i] = fe
+	}
+	return errs
+}
+
+// State shared by the goroutines of Walk.
+type walker struct {
+	c      *Corpus
+	ctx    context.Context
+	files  []string
+	fn     WalkFunc
+	output io.Writer
+	mutex  sync.Mutex
+	errs   WalkErrors
+}
+
+// Read, parse and examine file number i.
+func (w *walker) examine(i int) (f *WalkFile) {
+	f = &WalkFile{Name: w.files[i], Index: i}
+	contents, err := w.c.ReadFile(f.Name)
+	if err == nil {
+		f.Contents = contents
+		f.SVG, err = ParseKanji(contents)
+	}
+	if err == nil {
+		err = w.fn(f)
+	}
+	if err != nil {
+		w.mutex.Lock()
+		w.errs = append(w.errs, &FileError{File: f.Name, Err: err})
+		w.mutex.Unlock()
+	}
+	return f
+}
+
+// Write the output of f.
+func (w *walker) flush(f *WalkFile) {
+	if f.Out.Len() == 0 {
+		return
+	}
+	w.mutex.Lock()
+	w.output.Write(f.Out.Bytes())
+	w.mutex.Unlock()
+}
+
+// Send the indices of the files to jobs until all are sent or the
+// context is cancelled. If ahead is not nil, a value is sent to it
+// before each index, so that the files are kept at most cap(ahead)
+// ahead of whatever receives from ahead.
+func (w *walker) feed(jobs chan<- int, ahead chan struct{}) {
+	defer close(jobs)
+	for i := range w.files {
+		if ahead != nil {
+			select {
+			case ahead <- struct{}{}:
+			case <-w.ctx.Done():
+				return
+			}
+		}
+		select {
+		case jobs <- i:
+		case <-w.ctx.Done():
+			return
+		}
+	}
+}
+
+func (w *walker) unordered(workers int) {
+	jobs := make(chan int)
+	go w.feed(jobs, nil)
+	var wg sync.WaitGroup
+	for k := 0; k < workers; k++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				if w.ctx.Err() != nil {
+					continue
+				}
+				w.flush(w.examine(i))
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func (w *walker) ordered(workers int) {
+	jobs := make(chan int)
+	ahead := make(chan struct{}, 2*workers)
+	go w.feed(jobs, ahead)
+	done := make([]chan *WalkFile, len(w.files))
+	for i := range done {
+		done[i] = make(chan *WalkFile, 1)
+	}
+	var wg sync.WaitGroup
+	for k := 0; k < workers; k++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				if w.ctx.Err() != nil {
+					continue
+				}
+				done[i] <- w.examine(i)
+			}
+		}()
+	}
+	for i := range w.files {
+		var f *WalkFile
+		select {
+		case f = <-done[i]:
+		case <-w.ctx.Done():
+		}
+		if f == nil {
+			break
+		}
+		<-ahead
+		w.flush(f)
+	}
+	wg.Wait()
+}
+
+// Read and parse every file in the corpus, and call fn on each of
+// them, using several goroutines at once. An error reading or parsing
+// a file, or returned by fn, does not stop the walk. Instead the
+// errors of all the files are returned together as WalkErrors. If ctx
+// is cancelled, no more files are started and the return value is the
+// error of ctx.
+func (c *Corpus) Walk(ctx context.Context, opt WalkOptions, fn WalkFunc) (err error) {
+	files, err := c.Files()
+	if err != nil {
+		return err
+	}
+	w := walker{c: c, ctx: ctx, files: files, fn: fn, output: opt.Output}
+	if w.output == nil {
+		w.output = os.Stdout
+	}
+	workers := opt.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	if opt.Ordered {
+		w.ordered(workers)
+	} else {
+		w.unordered(workers)
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if len(w.errs) > 0 {
+		sort.Slice(w.errs, func(i, j int) bool {
+			return w.errs[i].File < w.errs[j].File
+		})
+		return w.errs
+	}
+	return nil
+}